@@ -0,0 +1,849 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dbravender/mtb/internal/tools"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// runFunc runs a command with its positional arguments. It returns the
+// tool's structured output (printed with --json) and a renderer for the
+// human-readable form.
+type runFunc func(ctx context.Context, args []string) (any, func(w io.Writer), error)
+
+// errCheckFailed is returned by a command whose check did not pass. Its
+// output is still printed, but the process exits with status 1.
+var errCheckFailed = errors.New("check failed")
+
+// command is a standalone CLI entry point that calls the same handler the
+// MCP server exposes as a tool.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	// setup registers the command's flags and returns the function that
+	// runs it once they are parsed.
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+var commands = []command{
+	{
+		name:    "stats",
+		usage:   "mtb stats [flags] [path]",
+		summary: "lines of code, complexity, and COCOMO estimates per language",
+		setup:   statsCommand,
+	},
+	{
+		name:    "consult",
+		usage:   "mtb consult [flags] <problem>",
+		summary: "questions to answer before building something new",
+		setup:   consultCommand,
+	},
+	{
+		name:    "answer",
+		usage:   "mtb answer [flags] [session-id] [answer]",
+		summary: "record answers to a consult session and its decision",
+		setup:   answerCommand,
+	},
+	{
+		name:    "adr",
+		usage:   "mtb adr [flags] [session-id]",
+		summary: "write an architecture decision record",
+		setup:   adrCommand,
+	},
+	{
+		name:    "checklist",
+		usage:   "mtb checklist [flags] <project>",
+		summary: "operational readiness checklist",
+		setup:   checklistCommand,
+	},
+	{
+		name:    "compare",
+		usage:   "mtb compare [flags] <project>",
+		summary: "measure the complexity impact of changes",
+		setup:   compareCommand,
+	},
+	{
+		name:    "hotspots",
+		usage:   "mtb hotspots [flags] [path]",
+		summary: "rank files that are both complex and frequently changed",
+		setup:   hotspotsCommand,
+	},
+	{
+		name:    "trend",
+		usage:   "mtb trend [flags] [path]",
+		summary: "chart complexity and cost across git history",
+		setup:   trendCommand,
+	},
+	{
+		name:    "gate",
+		usage:   "mtb gate [flags] [path]",
+		summary: "fail when staged changes exceed the complexity budget",
+		setup:   gateCommand,
+	},
+	{
+		name:    "snapshot",
+		usage:   "mtb snapshot [flags] <save|list|delete> [name]",
+		summary: "save stats under a name to compare against later",
+		setup:   snapshotCommand,
+	},
+	{
+		name:    "deps",
+		usage:   "mtb deps [flags] [path]",
+		summary: "existing dependencies to check before adding new ones",
+		setup:   depsCommand,
+	},
+	{
+		name:    "check",
+		usage:   "mtb check [flags] <package>",
+		summary: "whether the project or standard library already covers a package",
+		setup:   checkCommand,
+	},
+	{
+		name:    "weight",
+		usage:   "mtb weight [flags] [path]",
+		summary: "code, complexity, and cost each dependency brings in",
+		setup:   weightCommand,
+	},
+	{
+		name:    "preview",
+		usage:   "mtb preview [flags] <module>[@version]",
+		summary: "what adding a Go module would bring in",
+		setup:   previewCommand,
+	},
+}
+
+// isCommand reports whether name is a known CLI subcommand.
+func isCommand(name string) bool {
+	for _, c := range commands {
+		if c.name == name {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+// runCLI runs a subcommand and returns the process exit code.
+func runCLI(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "Usage: %s\n\n", c.usage)
+			fs.PrintDefaults()
+		}
+		asJSON := fs.Bool("json", false, "print structured output as JSON")
+		run := c.setup(fs)
+
+		positional, err := parseArgs(fs, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if err != nil {
+			return 2
+		}
+
+		data, render, err := run(ctx, positional)
+		failed := errors.Is(err, errCheckFailed)
+		if err != nil && !failed {
+			fmt.Fprintf(stderr, "mtb %s: %v\n", c.name, err)
+			return 1
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(data); err != nil {
+				fmt.Fprintf(stderr, "mtb %s: %v\n", c.name, err)
+				return 1
+			}
+		} else {
+			render(stdout)
+		}
+		if failed {
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "mtb: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: mtb [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, mtb serves its tools over MCP on stdio, or over")
+	fmt.Fprintln(w, "streamable HTTP with --http :8080.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'mtb <command> -h' for command flags. Every command accepts --json.")
+}
+
+// parseArgs parses flags that may appear before or after positional
+// arguments, so both "mtb stats --json ." and "mtb stats . --json" work.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// listFlag collects repeatable, comma-separated flag values.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// repeatFlag collects repeatable flag values that may contain commas.
+type repeatFlag []string
+
+func (r *repeatFlag) String() string { return strings.Join(*r, "; ") }
+
+func (r *repeatFlag) Set(v string) error {
+	*r = append(*r, v)
+	return nil
+}
+
+// callTool invokes a tool handler outside of an MCP session and converts an
+// error result into a Go error.
+func callTool[In, Out any](ctx context.Context, h mcp.ToolHandlerFor[In, Out], input In) (Out, error) {
+	result, output, err := h(ctx, &mcp.CallToolRequest{}, input)
+	if err != nil {
+		return output, err
+	}
+	if result != nil && result.IsError {
+		return output, errors.New(resultText(result))
+	}
+	return output, nil
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func statsCommand(fs *flag.FlagSet) runFunc {
+	var excludeDir, excludeExt, includeExt listFlag
+	fs.Var(&excludeDir, "exclude-dir", "directories to exclude (repeatable, comma-separated)")
+	fs.Var(&excludeExt, "exclude-ext", "file extensions to exclude (repeatable, comma-separated)")
+	fs.Var(&includeExt, "include-ext", "only include these file extensions (repeatable, comma-separated)")
+	noCocomo := fs.Bool("no-cocomo", false, "omit COCOMO cost estimates")
+	noComplexity := fs.Bool("no-complexity", false, "omit complexity metrics")
+	files := fs.Bool("files", false, "include a per-file breakdown")
+	topN := fs.Int("top", 0, "rank the N most complex files")
+	baseline := fs.String("baseline", "", "saved snapshot to diff against")
+	projectType := fs.String("project-type", "", "COCOMO project type: organic, semi-detached, or embedded")
+	wage := fs.Int64("wage", 0, "average annual developer wage for COCOMO")
+	overhead := fs.Float64("overhead", 0, "overhead multiplier on wages for COCOMO")
+	eaf := fs.Float64("eaf", 0, "COCOMO effort adjustment factor")
+	currency := fs.String("currency", "", "currency symbol for cost estimates")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one path")
+		}
+		input := tools.StatsInput{
+			ExcludeDir:        excludeDir,
+			ExcludeExtensions: excludeExt,
+			IncludeExtensions: includeExt,
+			Files:             *files,
+			TopN:              *topN,
+			Baseline:          *baseline,
+			ProjectType:       *projectType,
+			AverageWage:       *wage,
+			Overhead:          *overhead,
+			EAF:               *eaf,
+			Currency:          *currency,
+		}
+		if len(args) == 1 {
+			input.Path = args[0]
+		}
+		if *noCocomo {
+			input.Cocomo = new(bool)
+		}
+		if *noComplexity {
+			input.Complexity = new(bool)
+		}
+
+		out, err := callTool(ctx, tools.HandleStats, input)
+		return out, func(w io.Writer) { renderStats(w, out) }, err
+	}
+}
+
+func renderStats(w io.Writer, out tools.StatsOutput) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Language\tFiles\tLines\tCode\tComments\tBlanks\tComplexity\t")
+	for _, l := range out.LanguageSummary {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t\n", l.Name, l.Count, l.Lines, l.Code, l.Comment, l.Blank, l.Complexity)
+	}
+	tw.Flush()
+	if out.EstimatedCost > 0 && out.Cocomo != nil {
+		fmt.Fprintf(w, "\nEstimated cost: %s | People: %.2f | Schedule: %.1f months\n",
+			tools.FormatMoney(out.EstimatedCost, out.Cocomo.Currency), out.EstimatedPeople, out.EstimatedScheduleMonths)
+		fmt.Fprintf(w, "Model: %s\n", out.Cocomo)
+	}
+	if len(out.Files) > 0 {
+		fmt.Fprintln(w, "\nFiles:")
+		renderFiles(w, out.Files, false)
+	}
+	if len(out.MostComplex) > 0 {
+		fmt.Fprintln(w, "\nMost complex files:")
+		renderFiles(w, out.MostComplex, true)
+	}
+	renderWarnings(w, out.Warnings)
+	if out.Baseline != nil {
+		fmt.Fprintf(w, "\nSince %s:\n\n%s", out.Baseline.Base, out.Baseline.Table)
+		renderWarnings(w, out.Baseline.Warnings)
+	}
+}
+
+func renderWarnings(w io.Writer, warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "\nWarning: %s\n", warning)
+	}
+}
+
+func renderFiles(w io.Writer, files []tools.FileSummary, ranked bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, f := range files {
+		prefix := "  "
+		if ranked {
+			prefix = fmt.Sprintf("  %d.", i+1)
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\tcode %d\tcomplexity %d\t%d bytes\n", prefix, f.Location, f.Language, f.Code, f.Complexity, f.Bytes)
+	}
+	tw.Flush()
+}
+
+func consultCommand(fs *flag.FlagSet) runFunc {
+	path := fs.String("path", "", "project directory to scan for existing dependencies")
+	language := fs.String("language", "", "only return repositories written in this language")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		out, err := callTool(ctx, tools.HandleConsult, tools.ConsultInput{
+			Problem:  strings.Join(args, " "),
+			Path:     *path,
+			Language: *language,
+		})
+		return out, func(w io.Writer) {
+			if out.SessionID != "" {
+				fmt.Fprintf(w, "Session %s\n\n", out.SessionID)
+			}
+			for i, q := range out.Questions {
+				fmt.Fprintf(w, "%d. %s\n", i+1, q)
+			}
+			if len(out.Alternatives) > 0 {
+				fmt.Fprintln(w, "\nExisting alternatives:")
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				for _, alt := range out.Alternatives {
+					fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", alt.Name, alt.Kind, alt.License, alt.Deploy, alt.URL)
+				}
+				tw.Flush()
+			}
+			if len(out.Repositories) > 0 {
+				fmt.Fprintln(w, "\nMatching repositories:")
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				for _, repo := range out.Repositories {
+					fmt.Fprintf(tw, "  %s\t%d stars\t%s\t%s\tpushed %s\t%s\n", repo.FullName, repo.Stars, repo.License, repo.Language, repo.PushedAt.Format("2006-01-02"), repo.URL)
+				}
+				tw.Flush()
+			}
+			fmt.Fprintf(w, "\n%s\n", out.Guidance)
+		}, err
+	}
+}
+
+func answerCommand(fs *flag.FlagSet) runFunc {
+	path := fs.String("path", "", "project directory the session was started in (default .)")
+	question := fs.Int("question", 0, "number of the question being answered")
+	resolution := fs.String("resolution", "", "decision: build, buy/adopt, or abandon")
+	rationale := fs.String("rationale", "", "why the decision was made")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		input := tools.ConsultAnswerInput{
+			Path:       *path,
+			QuestionID: *question,
+			Resolution: *resolution,
+			Rationale:  *rationale,
+		}
+		if len(args) > 0 {
+			input.SessionID = args[0]
+			input.Answer = strings.Join(args[1:], " ")
+		}
+		out, err := callTool(ctx, tools.HandleConsultAnswer, input)
+		return out, func(w io.Writer) {
+			if out.Session == nil {
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				for _, s := range out.Sessions {
+					status := fmt.Sprintf("%d pending", s.Pending)
+					if s.Resolution != "" {
+						status = s.Resolution
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.ID, s.UpdatedAt.Local().Format("2006-01-02 15:04"), status, s.Problem)
+				}
+				tw.Flush()
+				fmt.Fprintf(w, "\n%s\n", out.Guidance)
+				return
+			}
+			fmt.Fprintf(w, "Session %s: %s\n", out.Session.ID, out.Session.Problem)
+			if out.Session.Resolution != "" {
+				fmt.Fprintf(w, "Resolved: %s\n", out.Session.Resolution)
+			}
+			if len(out.Pending) > 0 {
+				fmt.Fprintln(w, "\nPending questions:")
+				for _, q := range out.Pending {
+					fmt.Fprintf(w, "%d. %s\n", q.ID, q.Question)
+				}
+			}
+			fmt.Fprintf(w, "\n%s\n", out.Guidance)
+		}, err
+	}
+}
+
+func adrCommand(fs *flag.FlagSet) runFunc {
+	var alternatives, consequences repeatFlag
+	path := fs.String("path", "", "project directory (default .)")
+	title := fs.String("title", "", "record title (default derived from the decision)")
+	problem := fs.String("problem", "", "the problem being decided")
+	fs.Var(&alternatives, "alternative", "an option considered (repeatable)")
+	decision := fs.String("decision", "", "build, buy/adopt, abandon, or the decision in a sentence")
+	rationale := fs.String("rationale", "", "why the decision was made")
+	fs.Var(&consequences, "consequence", "what becomes easier or harder (repeatable)")
+	status := fs.String("status", "", "record status (default accepted)")
+	format := fs.String("format", "", "madr or nygard")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one session ID")
+		}
+		input := tools.ADRInput{
+			Path:         *path,
+			Title:        *title,
+			Problem:      *problem,
+			Alternatives: alternatives,
+			Decision:     *decision,
+			Rationale:    *rationale,
+			Consequences: consequences,
+			Status:       *status,
+			Format:       *format,
+		}
+		if len(args) == 1 {
+			input.SessionID = args[0]
+		}
+		out, err := callTool(ctx, tools.HandleADR, input)
+		return out, func(w io.Writer) {
+			if out.Index != "" {
+				fmt.Fprintf(w, "Wrote ADR %d to %s and updated %s\n", out.Number, out.File, out.Index)
+			} else {
+				fmt.Fprintf(w, "Wrote ADR %d to %s; the index was written by hand and left alone\n", out.Number, out.File)
+			}
+		}, err
+	}
+}
+
+func checklistCommand(fs *flag.FlagSet) runFunc {
+	path := fs.String("path", "", "project directory to scan for evidence")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		out, err := callTool(ctx, tools.HandleChecklist, tools.ChecklistInput{
+			Project: strings.Join(args, " "),
+			Path:    *path,
+		})
+		return out, func(w io.Writer) {
+			for i, item := range out.Items {
+				fmt.Fprintf(w, "%d. %s\n   %s\n   %s\n", i+1, item.Category, item.Question, item.Description)
+				if item.Status != "" {
+					fmt.Fprintf(w, "   Status: %s\n", item.Status)
+				}
+				for _, e := range item.Evidence {
+					fmt.Fprintf(w, "     - %s\n", e)
+				}
+			}
+			fmt.Fprintf(w, "\n%s\n", out.Guidance)
+		}, err
+	}
+}
+
+func compareCommand(fs *flag.FlagSet) runFunc {
+	path := fs.String("path", "", "directory inside the git repository to compare (default .)")
+	base := fs.String("base", "", "git ref for the before side (default HEAD)")
+	head := fs.String("head", "", "git ref for the after side (default: the working tree)")
+	baseSnapshot := fs.String("base-snapshot", "", "saved snapshot to use as the before side instead of a git ref")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		out, err := callTool(ctx, tools.HandleCompare, tools.CompareInput{
+			Project:      strings.Join(args, " "),
+			Path:         *path,
+			Base:         *base,
+			Head:         *head,
+			BaseSnapshot: *baseSnapshot,
+		})
+		return out, func(w io.Writer) {
+			fmt.Fprintf(w, "%s → %s\n\n%s", out.Base, out.Head, out.Table)
+			renderWarnings(w, out.Warnings)
+		}, err
+	}
+}
+
+func hotspotsCommand(fs *flag.FlagSet) runFunc {
+	var excludeDir, excludeExt, includeExt listFlag
+	since := fs.String("since", "", "only count commits newer than this git date (default 1 year ago)")
+	maxCommits := fs.Int("max-commits", 0, "only count the N most recent commits")
+	topN := fs.Int("top", 0, "number of hotspots to show (default 10)")
+	fs.Var(&excludeDir, "exclude-dir", "directories to exclude (repeatable, comma-separated)")
+	fs.Var(&excludeExt, "exclude-ext", "file extensions to exclude (repeatable, comma-separated)")
+	fs.Var(&includeExt, "include-ext", "only include these file extensions (repeatable, comma-separated)")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one path")
+		}
+		input := tools.HotspotsInput{
+			Since:             *since,
+			MaxCommits:        *maxCommits,
+			TopN:              *topN,
+			ExcludeDir:        excludeDir,
+			ExcludeExtensions: excludeExt,
+			IncludeExtensions: includeExt,
+		}
+		if len(args) == 1 {
+			input.Path = args[0]
+		}
+		out, err := callTool(ctx, tools.HandleHotspots, input)
+		return out, func(w io.Writer) {
+			fmt.Fprintf(w, "Since %s (%d commits)\n\n", out.Since, out.Commits)
+			if len(out.Hotspots) == 0 {
+				fmt.Fprintln(w, "No hotspots.")
+				return
+			}
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			for i, h := range out.Hotspots {
+				fmt.Fprintf(tw, "  %d. %s\t%d commits\t+%d -%d\tcomplexity %d\tscore %d\n", i+1, h.Location, h.Commits, h.LinesAdded, h.LinesDeleted, h.Complexity, h.Score)
+			}
+			tw.Flush()
+		}, err
+	}
+}
+
+func trendCommand(fs *flag.FlagSet) runFunc {
+	var excludeDir, excludeExt, includeExt listFlag
+	since := fs.String("since", "", "only sample commits newer than this git date (default 1 year ago)")
+	interval := fs.String("interval", "", "sample the last commit of each week or month (default month)")
+	every := fs.Int("every", 0, "sample every N commits instead of by interval")
+	maxPoints := fs.Int("max-points", 0, "keep at most this many of the most recent samples (default 52)")
+	render := fs.String("render", "", "also render the series as a sparkline or csv")
+	fs.Var(&excludeDir, "exclude-dir", "directories to exclude (repeatable, comma-separated)")
+	fs.Var(&excludeExt, "exclude-ext", "file extensions to exclude (repeatable, comma-separated)")
+	fs.Var(&includeExt, "include-ext", "only include these file extensions (repeatable, comma-separated)")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one path")
+		}
+		input := tools.TrendInput{
+			Since:             *since,
+			Interval:          *interval,
+			Every:             *every,
+			MaxPoints:         *maxPoints,
+			Render:            *render,
+			ExcludeDir:        excludeDir,
+			ExcludeExtensions: excludeExt,
+			IncludeExtensions: includeExt,
+		}
+		if len(args) == 1 {
+			input.Path = args[0]
+		}
+		out, err := callTool(ctx, tools.HandleTrend, input)
+		return out, func(w io.Writer) {
+			// CSV goes to stdout alone so it can be redirected to a file.
+			if out.CSV != "" {
+				fmt.Fprint(w, out.CSV)
+				return
+			}
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(tw, "Date\tCommit\tCode\tComplexity\tEst. cost\t")
+			for _, p := range out.Points {
+				fmt.Fprintf(tw, "%s\t%.7s\t%d\t%d\t%s\t\n", p.Date.Local().Format("2006-01-02"), p.Commit, p.Code, p.Complexity, tools.FormatMoney(p.EstimatedCost, out.Cocomo.Currency))
+			}
+			tw.Flush()
+			if out.Sparkline != "" {
+				fmt.Fprintf(w, "\n%s", out.Sparkline)
+			}
+		}, err
+	}
+}
+
+func gateCommand(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one path")
+		}
+		input := tools.GateInput{}
+		if len(args) == 1 {
+			input.Path = args[0]
+		}
+		out, err := callTool(ctx, tools.HandleGate, input)
+		if err == nil && !out.Passed {
+			err = errCheckFailed
+		}
+		return out, func(w io.Writer) {
+			if out.Passed {
+				fmt.Fprintln(w, "Staged changes are within the complexity budget.")
+			} else {
+				fmt.Fprintln(w, "Complexity budget exceeded:")
+				for _, v := range out.Violations {
+					fmt.Fprintf(w, "  - %s\n", v.Message)
+				}
+			}
+			fmt.Fprintf(w, "\nHEAD → staged\n\n%s", out.Delta.Table)
+		}, err
+	}
+}
+
+func snapshotCommand(fs *flag.FlagSet) runFunc {
+	var excludeDir, excludeExt, includeExt listFlag
+	path := fs.String("path", "", "directory to analyze (default .)")
+	fs.Var(&excludeDir, "exclude-dir", "directories to exclude (repeatable, comma-separated)")
+	fs.Var(&excludeExt, "exclude-ext", "file extensions to exclude (repeatable, comma-separated)")
+	fs.Var(&includeExt, "include-ext", "only include these file extensions (repeatable, comma-separated)")
+
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) == 0 || len(args) > 2 {
+			return nil, nil, errors.New("expected an action (save, list, or delete) and a name")
+		}
+		input := tools.SnapshotInput{
+			Action:            args[0],
+			Path:              *path,
+			ExcludeDir:        excludeDir,
+			ExcludeExtensions: excludeExt,
+			IncludeExtensions: includeExt,
+		}
+		if len(args) == 2 {
+			input.Name = args[1]
+		}
+		out, err := callTool(ctx, tools.HandleSnapshot, input)
+		return out, func(w io.Writer) {
+			switch {
+			case out.Snapshot != nil:
+				fmt.Fprintf(w, "Saved snapshot %q of %s", out.Snapshot.Name, out.Snapshot.Path)
+				if out.Snapshot.Commit != "" {
+					fmt.Fprintf(w, " at %.12s", out.Snapshot.Commit)
+				}
+				fmt.Fprintln(w)
+			case out.Deleted != "":
+				fmt.Fprintf(w, "Deleted snapshot %q\n", out.Deleted)
+			case len(out.Snapshots) > 0:
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "Name\tCreated\tCommit\tPath\tCode\tComplexity")
+				for _, snap := range out.Snapshots {
+					fmt.Fprintf(tw, "%s\t%s\t%.12s\t%s\t%d\t%d\n", snap.Name, snap.CreatedAt.Local().Format("2006-01-02 15:04"), snap.Commit, snap.Path, snap.Code, snap.Complexity)
+				}
+				tw.Flush()
+			default:
+				fmt.Fprintln(w, out.Guidance)
+			}
+		}, err
+	}
+}
+
+func depsCommand(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one path")
+		}
+		input := tools.DepsInput{}
+		if len(args) == 1 {
+			input.Path = args[0]
+		}
+		out, err := callTool(ctx, tools.HandleDeps, input)
+		return out, func(w io.Writer) {
+			if len(out.Dependencies) > 0 {
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "Ecosystem\tName\tVersion\tType\tManifest")
+				for _, d := range out.Dependencies {
+					kind := "direct"
+					if !d.Direct {
+						kind = "indirect"
+					}
+					if d.Kind != "" {
+						kind += " (" + d.Kind + ")"
+					}
+					version := d.Version
+					if d.Replace != "" {
+						version += " => " + d.Replace
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s:%d\n", d.Ecosystem, d.Name, version, kind, d.Manifest, d.Line)
+				}
+				tw.Flush()
+				fmt.Fprintln(w)
+			}
+			if len(out.Duplicates) > 0 {
+				fmt.Fprintln(w, "Duplicate functionality:")
+				for _, d := range out.Duplicates {
+					fmt.Fprintf(w, "  %s (%s): %s\n", d.Name, d.Ecosystem, strings.Join(d.Packages, ", "))
+				}
+				fmt.Fprintln(w)
+			}
+			if len(out.Lockfiles) > 0 {
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "Lockfile\tEcosystem\tDirect\tTransitive\tHeaviest")
+				for _, l := range out.Lockfiles {
+					heaviest := "-"
+					if len(l.Subtrees) > 0 {
+						heaviest = fmt.Sprintf("%s (%d, %d exclusive)", l.Subtrees[0].Name, l.Subtrees[0].Transitive, l.Subtrees[0].Exclusive)
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", l.Lockfile, l.Ecosystem, l.Direct, l.Transitive, heaviest)
+				}
+				tw.Flush()
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, out.Guidance)
+		}, err
+	}
+}
+
+func checkCommand(fs *flag.FlagSet) runFunc {
+	path := fs.String("path", "", "project directory whose manifests to compare against (default .)")
+	ecosystem := fs.String("ecosystem", "", "package ecosystem: go, npm, pypi, or cargo (inferred when omitted)")
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) != 1 {
+			return nil, nil, errors.New("expected exactly one package")
+		}
+		out, err := callTool(ctx, tools.HandleCheckDependency, tools.CheckDependencyInput{
+			Package:   args[0],
+			Path:      *path,
+			Ecosystem: *ecosystem,
+		})
+		return out, func(w io.Writer) {
+			fmt.Fprintf(w, "%s (%s): %s\n", out.Package, out.Ecosystem, out.Reason)
+			if len(out.CoveredBy) > 0 {
+				fmt.Fprintln(w)
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "Covered by\tVersion\tType\tManifest")
+				for _, d := range out.CoveredBy {
+					kind := "direct"
+					if !d.Direct {
+						kind = "indirect"
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s:%d\n", d.Name, d.Version, kind, d.Manifest, d.Line)
+				}
+				tw.Flush()
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, out.Guidance)
+		}, err
+	}
+}
+
+func weightCommand(fs *flag.FlagSet) runFunc {
+	indirect := fs.Bool("indirect", false, "also weigh indirect dependencies listed in the manifests")
+	topN := fs.Int("top", 0, "number of dependencies to show (default 20)")
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) > 1 {
+			return nil, nil, errors.New("expected at most one path")
+		}
+		input := tools.DepWeightInput{IncludeIndirect: *indirect, TopN: *topN}
+		if len(args) == 1 {
+			input.Path = args[0]
+		}
+		out, err := callTool(ctx, tools.HandleDepWeight, input)
+		return out, func(w io.Writer) {
+			if len(out.Dependencies) > 0 {
+				tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "Ecosystem\tName\tVersion\tFiles\tCode\tComplexity\tEst. cost")
+				for _, d := range out.Dependencies {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n", d.Ecosystem, d.Name, d.Version, d.Files, d.Code, d.Complexity, tools.FormatMoney(d.EstimatedCost, out.Cocomo.Currency))
+				}
+				tw.Flush()
+				fmt.Fprintf(w, "\nTotal: %d lines of code, complexity %d, %s\nModel: %s\n\n", out.Code, out.Complexity, tools.FormatMoney(out.EstimatedCost, out.Cocomo.Currency), out.Cocomo)
+			}
+			if len(out.Missing) > 0 {
+				fmt.Fprintln(w, "Not on disk:")
+				for _, m := range out.Missing {
+					fmt.Fprintf(w, "  %s (%s): %s\n", m.Name, m.Ecosystem, m.Reason)
+				}
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, out.Guidance)
+		}, err
+	}
+}
+
+func previewCommand(fs *flag.FlagSet) runFunc {
+	path := fs.String("path", "", "directory containing the project's go.mod (default .)")
+	goproxy := fs.String("goproxy", "", "GOPROXY to resolve modules with (default preview.goproxy in .mtb.yaml, else off)")
+	return func(ctx context.Context, args []string) (any, func(io.Writer), error) {
+		if len(args) != 1 {
+			return nil, nil, errors.New("expected exactly one module")
+		}
+		module, version, _ := strings.Cut(args[0], "@")
+		out, err := callTool(ctx, tools.HandlePreviewDependency, tools.PreviewDependencyInput{
+			Module:  module,
+			Version: version,
+			Path:    *path,
+			GoProxy: *goproxy,
+		})
+		return out, func(w io.Writer) {
+			fmt.Fprintf(w, "%s → %s\n\n", out.Base, out.Head)
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "Module\tVersion\tLicense\tCode\tComplexity")
+			for _, m := range out.NewModules {
+				fmt.Fprintf(tw, "%s\t%s (new)\t%s\t%d\t%d\n", m.Path, m.Version, m.License, m.Code, m.Complexity)
+			}
+			for _, m := range out.Changed {
+				fmt.Fprintf(tw, "%s\t%s → %s\t%s\t%d\t%d\n", m.Path, m.Previous, m.Version, m.License, m.Code, m.Complexity)
+			}
+			tw.Flush()
+			fmt.Fprintf(w, "\n%d new go.sum lines\n\n%s", len(out.GoSum), out.Table)
+			renderWarnings(w, out.Warnings)
+		}, err
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dbravender/mtb/internal/tools"
+)
+
+func TestMain(m *testing.M) {
+	// Analyses re-execute the test binary as an scc worker.
+	tools.ServeWorker()
+	// Tests never reach the network; search tests install their own provider.
+	tools.SetSearchProvider(tools.OfflineSearch{})
+	// Unanswered consult sessions are saved in the user cache.
+	cache, err := os.MkdirTemp("", "mtb-cache-*")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CACHE_HOME", cache)
+	code := m.Run()
+	os.RemoveAll(cache)
+	tools.CloseWorkers()
+	os.Exit(code)
+}
+
+func TestRunCLI_StatsJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"stats", dir, "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	var output tools.StatsOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("expected JSON output: %v", err)
+	}
+	if len(output.LanguageSummary) != 1 || output.LanguageSummary[0].Name != "Go" {
+		t.Fatalf("expected Go summary, got %+v", output.LanguageSummary)
+	}
+}
+
+func TestRunCLI_ConsultText(t *testing.T) {
+	// consult looks for .mtb.yaml from the working directory.
+	t.Chdir(t.TempDir())
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"consult", "build", "a", "wiki"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"build a wiki"`) {
+		t.Fatalf("expected problem in output, got %q", stdout.String())
+	}
+}
+
+func TestRunCLI_ToolError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"checklist"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "project is required") {
+		t.Fatalf("expected tool error on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunCLI_UnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"frobnicate"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunCLI_GateOverBudget(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		".mtb.yaml": "budget:\n  code: 1\n",
+		"main.go":   "package main\n\nfunc main() {\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"gate", dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "project code lines grew by 5, over the budget of 1") {
+		t.Fatalf("expected an explanation on stdout, got %q", stdout.String())
+	}
+}
+
+func TestRunCLI_Answer(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"consult", "build a wiki", "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var consult tools.ConsultOutput
+	if err := json.Unmarshal(stdout.Bytes(), &consult); err != nil {
+		t.Fatalf("expected JSON output: %v", err)
+	}
+
+	stdout.Reset()
+	args := []string{"answer", "--question", "1", consult.SessionID, "because", "the", "docs", "are", "scattered"}
+	if code := runCLI(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Pending questions:") {
+		t.Fatalf("expected pending questions, got %q", stdout.String())
+	}
+}
+
+func TestRunCLI_TrendCurrency(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		".mtb.yaml": "cocomo:\n  currency: €\n",
+		"main.go":   "package main\n\nfunc main() {\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "init"}} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"trend", "--every", "1", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "€") || strings.Contains(stdout.String(), "$") {
+		t.Fatalf("expected costs in the configured currency, got %q", stdout.String())
+	}
+}
+
+func TestRenderStats_Cost(t *testing.T) {
+	var out bytes.Buffer
+	renderStats(&out, tools.StatsOutput{EstimatedCost: 374064, Cocomo: &tools.CocomoModel{ProjectType: "organic", AverageWage: 56286, Currency: "$"}})
+	if !strings.Contains(out.String(), "Estimated cost: $374,064 |") {
+		t.Fatalf("expected the cost with thousands separators, got %q", out.String())
+	}
+}
@@ -5,6 +5,8 @@ go 1.26.0
 require (
 	github.com/boyter/scc/v3 v3.6.0
 	github.com/modelcontextprotocol/go-sdk v1.3.0
+	github.com/yosida95/uritemplate/v3 v3.0.2
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
@@ -23,7 +25,6 @@ require (
 	github.com/rogpeppe/go-internal v1.14.1 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
-	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/crypto v0.48.0 // indirect
 	golang.org/x/oauth2 v0.35.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
@@ -31,5 +32,4 @@ require (
 	golang.org/x/text v0.34.0 // indirect
 	golang.org/x/tools v0.42.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
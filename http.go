@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// shutdownTimeout bounds how long in-flight requests and open event streams
+// may take to finish once the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// newHTTPHandler serves the MCP streamable HTTP transport at / and a
+// liveness probe at /healthz.
+func newHTTPHandler(server *mcp.Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("ok\n"))
+	})
+	mux.Handle("/", mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, nil))
+	return mux
+}
+
+// serveHTTP serves server on addr until ctx is cancelled, then shuts down
+// gracefully.
+func serveHTTP(ctx context.Context, addr string, server *mcp.Server) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           newHTTPHandler(server),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("mtb %s serving MCP over HTTP on %s", version, addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// Long-lived event streams may outlast the timeout; drop them.
+		srv.Close()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
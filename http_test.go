@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHTTPHandler_Healthz(t *testing.T) {
+	ts := httptest.NewServer(newHTTPHandler(newServer()))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if string(body) != "ok\n" {
+		t.Fatalf("expected ok body, got %q", body)
+	}
+}
+
+func TestHTTPHandler_ListTools(t *testing.T) {
+	ts := httptest.NewServer(newHTTPHandler(newServer()))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	session, err := client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: ts.URL, DisableStandaloneSSE: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	res, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	for _, tool := range res.Tools {
+		names[tool.Name] = true
+	}
+	for _, want := range []string{"stats", "deps", "consult", "checklist", "compare", "hotspots", "trend", "check_dependency", "dep_weight", "preview_dependency"} {
+		if !names[want] {
+			t.Errorf("expected tool %q over HTTP", want)
+		}
+	}
+}
+
+func TestServeHTTP_GracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveHTTP(ctx, "127.0.0.1:0", newServer()) }()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestHTTPHandler_ListPrompts(t *testing.T) {
+	ts := httptest.NewServer(newHTTPHandler(newServer()))
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	session, err := client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: ts.URL, DisableStandaloneSSE: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	res, err := session.ListPrompts(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	for _, prompt := range res.Prompts {
+		names[prompt.Name] = true
+	}
+	for _, want := range []string{"mtb-consult", "mtb-checklist", "mtb-compare"} {
+		if !names[want] {
+			t.Errorf("expected prompt %q over HTTP", want)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultADRDir is where records are written, relative to the repository
+// root, unless the config says otherwise.
+const defaultADRDir = "docs/adr"
+
+// adrIndexNames are the files the index may be kept in, in order of
+// preference. A file that exists without adrIndexMarker was written by hand
+// and is never overwritten.
+var adrIndexNames = []string{"README.md", "index.md"}
+
+// adrIndexMarker identifies an index mtb generated.
+const adrIndexMarker = "<!-- Maintained by mtb adr."
+
+// adrStatuses are the statuses a record may have.
+var adrStatuses = []string{"proposed", "accepted", "rejected", "deprecated", "superseded"}
+
+// ADR formats.
+const (
+	FormatMADR   = "madr"
+	FormatNygard = "nygard"
+)
+
+// adrFile matches record file names, e.g. 0001-use-zammad.md.
+var adrFile = regexp.MustCompile(`^(\d{4})-.+\.md$`)
+
+// adrMu serializes numbering so concurrent calls don't pick the same
+// number.
+var adrMu sync.Mutex
+
+type ADRAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+type ADRInput struct {
+	Path         string      `json:"path,omitempty" jsonschema:"project directory (default .); records go under its repository root"`
+	SessionID    string      `json:"session_id,omitempty" jsonschema:"consult session to record; supplies the problem, alternatives, answers, and decision"`
+	Title        string      `json:"title,omitempty" jsonschema:"short title of the problem and decision (default derived from the decision)"`
+	Problem      string      `json:"problem,omitempty" jsonschema:"the problem being decided (required without session_id)"`
+	Alternatives []string    `json:"alternatives,omitempty" jsonschema:"options considered, e.g. Zammad (AGPL-3.0, self-hosted)"`
+	Answers      []ADRAnswer `json:"answers,omitempty" jsonschema:"consultation questions and the user's answers"`
+	Decision     string      `json:"decision,omitempty" jsonschema:"build, buy/adopt, abandon, or a sentence such as Adopt Zammad (required without a resolved session)"`
+	Rationale    string      `json:"rationale,omitempty" jsonschema:"why the decision was made"`
+	Consequences []string    `json:"consequences,omitempty" jsonschema:"what becomes easier or harder because of the decision"`
+	Status       string      `json:"status,omitempty" jsonschema:"proposed, accepted (default), rejected, deprecated, or superseded"`
+	Format       string      `json:"format,omitempty" jsonschema:"madr or nygard (default madr, or the adr.format config)"`
+}
+
+// ADRSummary is an entry in the ADR index.
+type ADRSummary struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Status string `json:"status,omitempty"`
+	Date   string `json:"date,omitempty"`
+	File   string `json:"file"`
+}
+
+type ADROutput struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Format string `json:"format"`
+	// File and Index are relative to the repository root. Index is empty
+	// when every index file name is taken by a hand-written file.
+	File     string       `json:"file"`
+	Index    string       `json:"index,omitempty"`
+	Content  string       `json:"content"`
+	Records  []ADRSummary `json:"records"`
+	Guidance string       `json:"guidance"`
+}
+
+// adrRecord is a decision ready to render.
+type adrRecord struct {
+	Number       int
+	Title        string
+	Date         string
+	Status       string
+	Problem      string
+	Options      []string
+	Answers      []ADRAnswer
+	Decision     string
+	Rationale    string
+	Consequences []string
+	SessionID    string
+}
+
+func HandleADR(ctx context.Context, req *mcp.CallToolRequest, input ADRInput) (*mcp.CallToolResult, ADROutput, error) {
+	root := sessionRoot(ctx, input.Path)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return ErrResult[ADROutput](fmt.Sprintf("%s is not a directory", root))
+	}
+
+	cfg, err := LoadConfig(input.Path)
+	if err != nil {
+		return ErrResult[ADROutput](err.Error())
+	}
+	format := strings.ToLower(input.Format)
+	if format == "" {
+		format = strings.ToLower(cfg.ADR.Format)
+	}
+	if format == "" {
+		format = FormatMADR
+	}
+	if format != FormatMADR && format != FormatNygard {
+		return ErrResult[ADROutput](fmt.Sprintf("unknown format %q: use madr or nygard", format))
+	}
+	dir := cfg.ADR.Dir
+	if dir == "" {
+		dir = defaultADRDir
+	}
+	if filepath.IsAbs(dir) || !filepath.IsLocal(filepath.FromSlash(dir)) {
+		return ErrResult[ADROutput](fmt.Sprintf("adr.dir %q must be a path inside the repository", dir))
+	}
+
+	rec := adrRecord{
+		Problem:      input.Problem,
+		Options:      input.Alternatives,
+		Answers:      input.Answers,
+		Decision:     input.Decision,
+		Rationale:    input.Rationale,
+		Consequences: input.Consequences,
+		Status:       strings.ToLower(input.Status),
+		Date:         time.Now().Format("2006-01-02"),
+	}
+	if input.SessionID != "" {
+		s, err := loadSession(root, input.SessionID)
+		if err != nil {
+			return ErrResult[ADROutput](err.Error())
+		}
+		fromSession(&rec, s)
+	}
+	if rec.Problem == "" {
+		return ErrResult[ADROutput]("problem is required without a session_id")
+	}
+	if rec.Decision == "" {
+		if input.SessionID != "" {
+			return ErrResult[ADROutput](fmt.Sprintf("session %s has no decision yet; record the user's decision with consult_answer first", input.SessionID))
+		}
+		return ErrResult[ADROutput]("decision is required without a session_id")
+	}
+	rec.Title = input.Title
+	if rec.Title == "" {
+		rec.Title = adrTitle(rec.Decision, rec.Problem)
+	}
+	rec.Decision = decisionText(rec.Decision)
+	if rec.Status == "" {
+		rec.Status = "accepted"
+	}
+	if !slices.Contains(adrStatuses, rec.Status) {
+		return ErrResult[ADROutput](fmt.Sprintf("unknown status %q: use %s", input.Status, strings.Join(adrStatuses, ", ")))
+	}
+
+	adrMu.Lock()
+	defer adrMu.Unlock()
+
+	absDir := filepath.Join(root, filepath.FromSlash(dir))
+	records, err := listADRs(absDir)
+	if err != nil {
+		return ErrResult[ADROutput]("reading records: " + err.Error())
+	}
+	rec.Number = 1
+	if len(records) > 0 {
+		rec.Number = records[len(records)-1].Number + 1
+	}
+
+	var content string
+	if format == FormatNygard {
+		content = renderNygard(rec)
+	} else {
+		content = renderMADR(rec)
+	}
+	name := fmt.Sprintf("%04d-%s.md", rec.Number, slugify(rec.Title))
+	if err := writeNewFile(filepath.Join(absDir, name), content); err != nil {
+		return ErrResult[ADROutput]("writing record: " + err.Error())
+	}
+
+	records, err = listADRs(absDir)
+	if err != nil {
+		return ErrResult[ADROutput]("reading records: " + err.Error())
+	}
+	file := path.Join(filepath.ToSlash(dir), name)
+	output := ADROutput{
+		Number:  rec.Number,
+		Title:   rec.Title,
+		Format:  format,
+		File:    file,
+		Content: content,
+		Records: records,
+	}
+	if indexName, ok := adrIndexFile(absDir); ok {
+		if err := os.WriteFile(filepath.Join(absDir, indexName), []byte(renderADRIndex(records)), 0644); err != nil {
+			return ErrResult[ADROutput]("writing index: " + err.Error())
+		}
+		output.Index = path.Join(filepath.ToSlash(dir), indexName)
+		output.Guidance = fmt.Sprintf("ADR %d was written to %s and the index updated. ", rec.Number, file)
+	} else {
+		output.Guidance = fmt.Sprintf("ADR %d was written to %s. %s already exist and were written by hand, so mtb left them alone; "+
+			"ask the user whether to add the record to their index. ", rec.Number, file, strings.Join(adrIndexNames, " and "))
+	}
+	output.Guidance += "Show the user the record, ask them to correct anything that misstates their reasoning, " +
+		"and suggest committing it alongside the change it explains."
+	if len(rec.Consequences) == 0 {
+		output.Guidance += " The record has no consequences yet; ask the user what becomes easier or harder because of this decision and add them."
+	}
+	summary := fmt.Sprintf("Wrote ADR %d %q to %s.", rec.Number, rec.Title, file)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+	}, output, nil
+}
+
+// fromSession fills in whatever the caller did not supply from a consult
+// session.
+func fromSession(rec *adrRecord, s *ConsultSession) {
+	rec.SessionID = s.ID
+	if rec.Problem == "" {
+		rec.Problem = s.Problem
+	}
+	if len(rec.Options) == 0 {
+		rec.Options = append(rec.Options, "Build it ourselves")
+		for _, alt := range s.Alternatives {
+			rec.Options = append(rec.Options, fmt.Sprintf("%s (%s, %s, %s) — %s", alt.Name, alt.Kind, alt.License, alt.Deploy, alt.URL))
+		}
+		for _, repo := range s.Repositories {
+			desc := fmt.Sprintf("%d stars", repo.Stars)
+			if repo.License != "" {
+				desc += ", " + repo.License
+			}
+			rec.Options = append(rec.Options, fmt.Sprintf("%s (%s) — %s", repo.FullName, desc, repo.URL))
+		}
+	}
+	if len(rec.Answers) == 0 {
+		for _, q := range s.Questions {
+			if q.AnsweredAt != nil {
+				rec.Answers = append(rec.Answers, ADRAnswer{Question: q.Question, Answer: q.Answer})
+			}
+		}
+	}
+	if rec.Decision == "" {
+		rec.Decision = s.Resolution
+	}
+	if rec.Rationale == "" {
+		rec.Rationale = s.Rationale
+	}
+}
+
+// decisionText turns a session resolution into the chosen option; any
+// other decision is used as written.
+func decisionText(decision string) string {
+	switch r, _ := parseResolution(decision); r {
+	case ResolutionBuild:
+		return "Build it ourselves"
+	case ResolutionAdopt:
+		return "Adopt an existing solution"
+	case ResolutionAbandon:
+		return "Do not build it"
+	}
+	return decision
+}
+
+func adrTitle(decision, problem string) string {
+	switch r, _ := parseResolution(decision); r {
+	case ResolutionBuild:
+		return clip("Build in-house: "+problem, 80)
+	case ResolutionAdopt:
+		return clip("Adopt an existing solution: "+problem, 80)
+	case ResolutionAbandon:
+		return clip("Do not pursue: "+problem, 80)
+	}
+	return clip(decision, 80)
+}
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify makes a title safe for a file name.
+func slugify(title string) string {
+	slug := strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		return "decision"
+	}
+	return slug
+}
+
+func renderMADR(rec adrRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\nstatus: %s\ndate: %s\n---\n\n", rec.Status, rec.Date)
+	fmt.Fprintf(&b, "# %s\n\n", rec.Title)
+	fmt.Fprintf(&b, "## Context and Problem Statement\n\n%s\n", rec.Problem)
+	if len(rec.Answers) > 0 {
+		b.WriteString("\n## Decision Drivers\n\n")
+		writeAnswers(&b, rec.Answers)
+	}
+	if len(rec.Options) > 0 {
+		b.WriteString("\n## Considered Options\n\n")
+		writeList(&b, rec.Options)
+	}
+	fmt.Fprintf(&b, "\n## Decision Outcome\n\nChosen option: %q.\n", rec.Decision)
+	if rec.Rationale != "" {
+		fmt.Fprintf(&b, "\n%s\n", rec.Rationale)
+	}
+	if len(rec.Consequences) > 0 {
+		b.WriteString("\n### Consequences\n\n")
+		writeList(&b, rec.Consequences)
+	}
+	if rec.SessionID != "" {
+		fmt.Fprintf(&b, "\n## More Information\n\nRecorded from mtb consult session %s.\n", rec.SessionID)
+	}
+	return b.String()
+}
+
+func renderNygard(rec adrRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %d. %s\n\nDate: %s\n\n", rec.Number, rec.Title, rec.Date)
+	fmt.Fprintf(&b, "## Status\n\n%s\n\n", strings.ToUpper(rec.Status[:1])+rec.Status[1:])
+	fmt.Fprintf(&b, "## Context\n\n%s\n", rec.Problem)
+	if len(rec.Options) > 0 {
+		b.WriteString("\nOptions considered:\n\n")
+		writeList(&b, rec.Options)
+	}
+	if len(rec.Answers) > 0 {
+		b.WriteString("\nThe consultation covered:\n\n")
+		writeAnswers(&b, rec.Answers)
+	}
+	if rec.SessionID != "" {
+		fmt.Fprintf(&b, "\nRecorded from mtb consult session %s.\n", rec.SessionID)
+	}
+	fmt.Fprintf(&b, "\n## Decision\n\n%s.\n", strings.TrimSuffix(rec.Decision, "."))
+	if rec.Rationale != "" {
+		fmt.Fprintf(&b, "\n%s\n", rec.Rationale)
+	}
+	b.WriteString("\n## Consequences\n\n")
+	if len(rec.Consequences) > 0 {
+		writeList(&b, rec.Consequences)
+	} else {
+		b.WriteString("None recorded yet.\n")
+	}
+	return b.String()
+}
+
+func writeList(b *strings.Builder, items []string) {
+	for _, item := range items {
+		fmt.Fprintf(b, "* %s\n", item)
+	}
+}
+
+func writeAnswers(b *strings.Builder, answers []ADRAnswer) {
+	for _, a := range answers {
+		fmt.Fprintf(b, "* **%s** %s\n", a.Question, a.Answer)
+	}
+}
+
+// writeNewFile writes content to a file that must not exist yet.
+func writeNewFile(file, content string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// listADRs reads the records in dir, lowest number first. Records written
+// by hand are included as long as their names follow the NNNN-title.md
+// convention.
+func listADRs(dir string) ([]ADRSummary, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []ADRSummary
+	for _, e := range entries {
+		m := adrFile.FindStringSubmatch(e.Name())
+		if m == nil || e.IsDir() {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		rec, err := readADR(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		rec.Number, rec.File = n, e.Name()
+		records = append(records, rec)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Number < records[j].Number })
+	return records, nil
+}
+
+// nygardHeading matches the number prefix in a Nygard title.
+var nygardHeading = regexp.MustCompile(`^\d+\.\s+`)
+
+// readADR extracts the title, status, and date from a MADR or Nygard
+// record.
+func readADR(file string) (ADRSummary, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return ADRSummary{}, err
+	}
+	defer f.Close()
+
+	var rec ADRSummary
+	frontMatter, inStatus := false, false
+	sc := bufio.NewScanner(f)
+	for line := 0; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		switch {
+		case line == 0 && text == "---":
+			frontMatter = true
+		case frontMatter && text == "---":
+			frontMatter = false
+		case frontMatter:
+			if v, ok := strings.CutPrefix(text, "status:"); ok {
+				rec.Status = strings.TrimSpace(v)
+			} else if v, ok := strings.CutPrefix(text, "date:"); ok {
+				rec.Date = strings.TrimSpace(v)
+			}
+		case rec.Title == "" && strings.HasPrefix(text, "# "):
+			rec.Title = nygardHeading.ReplaceAllString(strings.TrimPrefix(text, "# "), "")
+		case strings.HasPrefix(text, "Date:") && rec.Date == "":
+			rec.Date = strings.TrimSpace(strings.TrimPrefix(text, "Date:"))
+		case text == "## Status":
+			inStatus = true
+		case inStatus && text != "":
+			rec.Status = strings.ToLower(text)
+			inStatus = false
+		}
+	}
+	return rec, sc.Err()
+}
+
+// adrIndexFile returns the name of the index file in dir that mtb may
+// write: the first one that is missing or that mtb generated.
+func adrIndexFile(dir string) (string, bool) {
+	for _, name := range adrIndexNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) || err == nil && strings.Contains(string(data), adrIndexMarker) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func renderADRIndex(records []ADRSummary) string {
+	var b strings.Builder
+	b.WriteString("# Architecture Decision Records\n\n")
+	b.WriteString(adrIndexMarker + " Edit the records, not this list. -->\n\n")
+	b.WriteString("| ADR | Title | Status | Date |\n|---|---|---|---|\n")
+	for _, r := range records {
+		fmt.Fprintf(&b, "| [%04d](%s) | %s | %s | %s |\n", r.Number, r.File, strings.ReplaceAll(r.Title, "|", `\|`), r.Status, r.Date)
+	}
+	return b.String()
+}
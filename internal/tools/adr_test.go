@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func writeADR(t *testing.T, input ADRInput) ADROutput {
+	t.Helper()
+	result, output, err := HandleADR(context.Background(), &mcp.CallToolRequest{}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil && result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
+	return output
+}
+
+func TestHandleADR_FromSession(t *testing.T) {
+	dir := t.TempDir()
+	_, consult, _ := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{Problem: "a helpdesk for support tickets", Path: dir})
+	answer(t, ConsultAnswerInput{SessionID: consult.SessionID, Path: dir, QuestionID: 2, Answer: "Zammad needs Elasticsearch, which we can't run."})
+
+	result, _, _ := HandleADR(context.Background(), &mcp.CallToolRequest{}, ADRInput{Path: dir, SessionID: consult.SessionID})
+	if !result.IsError {
+		t.Fatal("expected an error for an unresolved session")
+	}
+
+	answer(t, ConsultAnswerInput{SessionID: consult.SessionID, Path: dir, Resolution: "build", Rationale: "Only a ticket form is needed."})
+	output := writeADR(t, ADRInput{Path: dir, SessionID: consult.SessionID})
+
+	if output.Number != 1 || output.File != "docs/adr/0001-build-in-house-a-helpdesk-for-support-tickets.md" {
+		t.Fatalf("unexpected record: %d %s", output.Number, output.File)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(output.File)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"status: accepted",
+		"## Context and Problem Statement\n\na helpdesk for support tickets",
+		"* Zammad (oss, AGPL-3.0,",
+		"we can't run.",
+		`Chosen option: "Build it ourselves".`,
+		"Only a ticket form is needed.",
+		"session " + consult.SessionID,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the record:\n%s", want, data)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "docs", "adr", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), "| [0001](0001-build-in-house-a-helpdesk-for-support-tickets.md) | Build in-house: a helpdesk for support tickets | accepted |") {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+}
+
+func TestHandleADR_Nygard(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".mtb.yaml":                               "adr:\n  dir: decisions\n  format: nygard\n",
+		"decisions/0007-use-postgres.md":          "# 7. Use Postgres\n\nDate: 2024-01-02\n\n## Status\n\nSuperseded\n",
+		"decisions/notes.md":                      "# Not a record\n",
+		"decisions/0003-record-decisions.md.orig": "",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := writeADR(t, ADRInput{
+		Path:         dir,
+		Title:        "Adopt Cal.com for booking",
+		Problem:      "Customers need to book demos.",
+		Alternatives: []string{"Cal.com", "Build our own"},
+		Decision:     "Adopt Cal.com",
+		Consequences: []string{"We run another service."},
+	})
+	if output.Number != 8 || output.Format != FormatNygard || output.File != "decisions/0008-adopt-cal-com-for-booking.md" {
+		t.Fatalf("unexpected record: %+v", output)
+	}
+	for _, want := range []string{"# 8. Adopt Cal.com for booking", "## Status\n\nAccepted", "* Cal.com", "## Decision\n\nAdopt Cal.com.", "* We run another service."} {
+		if !strings.Contains(output.Content, want) {
+			t.Errorf("expected %q in the record:\n%s", want, output.Content)
+		}
+	}
+
+	if len(output.Records) != 2 || output.Records[0].Title != "Use Postgres" || output.Records[0].Status != "superseded" || output.Records[0].Date != "2024-01-02" {
+		t.Fatalf("unexpected index records: %+v", output.Records)
+	}
+}
+
+func TestHandleADR_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for _, input := range []ADRInput{
+		{Path: dir, Decision: "build"},
+		{Path: dir, Problem: "a wiki"},
+		{Path: dir, Problem: "a wiki", Decision: "build", Format: "rfc"},
+		{Path: dir, Problem: "a wiki", Decision: "build", Status: "done"},
+		{Path: dir, SessionID: "0123456789ab"},
+		{Path: filepath.Join(dir, "missing"), Problem: "a wiki", Decision: "build"},
+	} {
+		result, _, _ := HandleADR(context.Background(), &mcp.CallToolRequest{}, input)
+		if !result.IsError {
+			t.Errorf("expected an error for %+v", input)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs")); err == nil {
+		t.Error("expected no records to be written")
+	}
+}
+
+func TestHandleADR_HandWrittenIndex(t *testing.T) {
+	dir := t.TempDir()
+	readme := "# Our decisions\n\nSee the wiki.\n"
+	writeFiles(t, dir, map[string]string{"docs/adr/README.md": readme})
+
+	output := writeADR(t, ADRInput{Path: dir, Problem: "a wiki", Decision: "build", Status: "Proposed"})
+	if output.Index != "docs/adr/index.md" {
+		t.Fatalf("expected the index in index.md, got %q", output.Index)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "docs", "adr", "README.md")); string(data) != readme {
+		t.Errorf("the hand-written README.md was overwritten:\n%s", data)
+	}
+	if output.Records[0].Status != "proposed" {
+		t.Errorf("unexpected records: %+v", output.Records)
+	}
+
+	// A generated index.md is kept up to date; a hand-written one is not.
+	output = writeADR(t, ADRInput{Path: dir, Problem: "a forum", Decision: "build"})
+	index, _ := os.ReadFile(filepath.Join(dir, "docs", "adr", "index.md"))
+	if output.Index != "docs/adr/index.md" || !strings.Contains(string(index), "| [0002]") {
+		t.Errorf("expected index.md to list both records, got %q:\n%s", output.Index, index)
+	}
+	writeFiles(t, dir, map[string]string{"docs/adr/index.md": "# Index\n"})
+	output = writeADR(t, ADRInput{Path: dir, Problem: "a blog", Decision: "build"})
+	if output.Index != "" || !strings.Contains(output.Guidance, "left them alone") {
+		t.Errorf("expected both indexes to be left alone, got %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "docs", "adr", "index.md")); string(data) != "# Index\n" {
+		t.Errorf("the hand-written index.md was overwritten:\n%s", data)
+	}
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed catalog.json
+var catalogJSON []byte
+
+// Alternative is an existing product or project that already solves a
+// problem domain.
+type Alternative struct {
+	Name string `json:"name"`
+	// Kind is "oss" for self-hostable open source or "saas" for hosted
+	// products.
+	Kind     string `json:"kind"`
+	License  string `json:"license"`
+	Language string `json:"language,omitempty"`
+	Deploy   string `json:"deploy"`
+	URL      string `json:"url"`
+	Domain   string `json:"domain,omitempty"`
+}
+
+// CatalogDomain is a problem domain with the keywords that identify it and
+// its known alternatives.
+type CatalogDomain struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Keywords     []string      `json:"keywords"`
+	Alternatives []Alternative `json:"alternatives"`
+}
+
+// loadCatalog decodes the embedded catalog once. The catalog ships inside
+// the binary so consult works in air-gapped environments and gives the same
+// answer on every run.
+var loadCatalog = sync.OnceValue(func() []CatalogDomain {
+	var catalog struct {
+		Domains []CatalogDomain `json:"domains"`
+	}
+	if err := json.Unmarshal(catalogJSON, &catalog); err != nil {
+		panic("tools: invalid embedded catalog: " + err.Error())
+	}
+	for i := range catalog.Domains {
+		for j := range catalog.Domains[i].Alternatives {
+			catalog.Domains[i].Alternatives[j].Domain = catalog.Domains[i].ID
+		}
+	}
+	return catalog.Domains
+})
+
+// matchCatalog returns the catalog domains that match problem, best match
+// first. A domain scores a point for each keyword or alternative name found
+// at a word boundary; domains scoring under half of the best are dropped so
+// an incidental word does not drag in an unrelated domain.
+func matchCatalog(problem string) []CatalogDomain {
+	text := strings.ToLower(problem)
+
+	type scored struct {
+		domain CatalogDomain
+		score  int
+	}
+	var matches []scored
+	best := 0
+	for _, d := range loadCatalog() {
+		score := 0
+		for _, kw := range d.Keywords {
+			if containsWord(text, kw) {
+				score++
+			}
+		}
+		for _, alt := range d.Alternatives {
+			if containsWord(text, alt.Name) {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{d, score})
+			best = max(best, score)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	var domains []CatalogDomain
+	for _, m := range matches {
+		if m.score*2 >= best {
+			domains = append(domains, m.domain)
+		}
+	}
+	return domains
+}
+
+// containsWord reports whether phrase occurs in text starting at a word
+// boundary, so "ticket" matches "ticketing" but "sso" does not match
+// "lesson".
+func containsWord(text, phrase string) bool {
+	phrase = strings.ToLower(phrase)
+	if phrase == "" {
+		return false
+	}
+	for i := 0; ; {
+		j := strings.Index(text[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		i += j
+		if i == 0 || !isWordByte(text[i-1]) {
+			return true
+		}
+		i++
+	}
+}
+
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	domains := loadCatalog()
+	if len(domains) == 0 {
+		t.Fatal("expected embedded catalog to have domains")
+	}
+
+	seen := map[string]bool{}
+	for _, d := range domains {
+		if d.ID == "" || d.Name == "" || len(d.Keywords) == 0 || len(d.Alternatives) == 0 {
+			t.Errorf("domain %q is incomplete", d.ID)
+		}
+		if seen[d.ID] {
+			t.Errorf("duplicate domain id %q", d.ID)
+		}
+		seen[d.ID] = true
+		for _, alt := range d.Alternatives {
+			if alt.Name == "" || alt.License == "" || alt.Deploy == "" || alt.URL == "" {
+				t.Errorf("%s: alternative %q is incomplete", d.ID, alt.Name)
+			}
+			if alt.Kind != "oss" && alt.Kind != "saas" {
+				t.Errorf("%s: alternative %q has unknown kind %q", d.ID, alt.Name, alt.Kind)
+			}
+			if alt.Domain != d.ID {
+				t.Errorf("%s: alternative %q not tagged with its domain", d.ID, alt.Name)
+			}
+		}
+	}
+}
+
+// TestMatchCatalog_Examples checks that every prompt in examples/ maps to
+// the domain its mtb response recommended alternatives for.
+func TestMatchCatalog_Examples(t *testing.T) {
+	want := map[string]string{
+		"build-a-customer-survey-tool":    "surveys",
+		"build-a-scheduling-tool":         "scheduling",
+		"build-a-ticketing-system":        "helpdesk",
+		"build-an-analytics-dashboard":    "dashboards",
+		"build-an-internal-wiki":          "wiki",
+		"replace-hubspot-with-custom-crm": "crm",
+	}
+
+	for dir, domain := range want {
+		prompt, err := os.ReadFile(filepath.Join("..", "..", "examples", dir, "prompt.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches := matchCatalog(string(prompt))
+		if len(matches) == 0 || matches[0].ID != domain {
+			var ids []string
+			for _, m := range matches {
+				ids = append(ids, m.ID)
+			}
+			t.Errorf("%s: expected best match %q, got [%s]", dir, domain, strings.Join(ids, ", "))
+		}
+	}
+}
+
+func TestMatchCatalog_NoMatch(t *testing.T) {
+	if matches := matchCatalog("parse JSON"); len(matches) != 0 {
+		t.Fatalf("expected no catalog match, got %d", len(matches))
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	if !containsWord("we need a ticketing system", "ticket") {
+		t.Error("expected prefix match at word boundary")
+	}
+	if containsWord("a lesson plan", "sso") {
+		t.Error("expected no match inside a word")
+	}
+	if !containsWord("a lesson on sso", "SSO") || !containsWord("sso for staff", "sso") {
+		t.Error("expected a match after an earlier one inside a word and at the start")
+	}
+}
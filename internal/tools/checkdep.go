@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type CheckDependencyInput struct {
+	Package   string `json:"package" jsonschema:"candidate package to add, e.g. github.com/pkg/errors, axios, or httpx"`
+	Path      string `json:"path,omitempty" jsonschema:"project directory whose manifests to compare against (default .)"`
+	Ecosystem string `json:"ecosystem,omitempty" jsonschema:"package ecosystem: go, npm, pypi, or cargo (inferred when omitted)"`
+}
+
+// Verdicts returned by check_dependency.
+const (
+	// VerdictCovered means the project already depends on the candidate or
+	// on another package with the same capability.
+	VerdictCovered = "covered"
+	// VerdictStdlib means the language or its standard library does the
+	// candidate's job.
+	VerdictStdlib = "stdlib"
+	// VerdictNew means nothing in the project provides the capability yet.
+	VerdictNew = "new"
+)
+
+type CheckDependencyOutput struct {
+	Package   string `json:"package"`
+	Ecosystem string `json:"ecosystem"`
+	// Verdict is covered, stdlib, or new; Reason says why in a sentence.
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason"`
+	// Capability is the taxonomy ID of what the candidate provides, empty
+	// when mtb does not know the package.
+	Capability     string `json:"capability,omitempty"`
+	CapabilityName string `json:"capabilityName,omitempty"`
+	// CoveredBy lists the project's dependencies that already provide the
+	// capability, direct ones first.
+	CoveredBy []Dependency      `json:"coveredBy,omitempty"`
+	Stdlib    *StdlibEquivalent `json:"stdlib,omitempty"`
+	Guidance  string            `json:"guidance"`
+}
+
+func HandleCheckDependency(ctx context.Context, req *mcp.CallToolRequest, input CheckDependencyInput) (*mcp.CallToolResult, CheckDependencyOutput, error) {
+	candidate := strings.TrimSpace(input.Package)
+	if candidate == "" {
+		return ErrResult[CheckDependencyOutput]("package is required")
+	}
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+
+	deps, _, err := scanManifests(path)
+	if err != nil {
+		return ErrResult[CheckDependencyOutput]("cannot scan project: " + err.Error())
+	}
+	ecosystem, err := inferEcosystem(candidate, strings.ToLower(input.Ecosystem), deps)
+	if err != nil {
+		return ErrResult[CheckDependencyOutput](err.Error())
+	}
+
+	output := checkDependency(candidate, ecosystem, deps)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("%s (%s): %s", candidate, ecosystem, output.Reason)}},
+	}, output, nil
+}
+
+// checkDependency decides whether the project needs candidate. An existing
+// dependency on the candidate itself wins, then a standard library
+// equivalent, then another dependency with the same capability.
+func checkDependency(candidate, ecosystem string, deps []Dependency) CheckDependencyOutput {
+	out := CheckDependencyOutput{Package: candidate, Ecosystem: ecosystem}
+
+	capability, _ := lookupPackage(ecosystem, candidate, lookupCapability)
+	if capability != nil {
+		out.Capability, out.CapabilityName = capability.ID, capability.Name
+	}
+	out.Stdlib, _ = lookupPackage(ecosystem, candidate, lookupStdlib)
+
+	var self, same []Dependency
+	for _, d := range deps {
+		if d.Ecosystem != ecosystem {
+			continue
+		}
+		if providesPackage(ecosystem, d.Name, candidate) {
+			self = append(self, d)
+			continue
+		}
+		if capability != nil {
+			if c, ok := lookupCapability(d.Ecosystem, d.Name); ok && c.ID == capability.ID {
+				d.Capability = c.ID
+				same = append(same, d)
+			}
+		}
+	}
+	sortCoverage(self)
+	sortCoverage(same)
+
+	switch {
+	case len(self) > 0:
+		out.Verdict = VerdictCovered
+		out.CoveredBy = self
+		out.Reason = fmt.Sprintf("already covered by %s: the project depends on it in %s", self[0].Name, self[0].Manifest)
+		if !self[0].Direct {
+			out.Reason += " (indirectly)"
+		}
+		out.Guidance = "The candidate is already in the project's dependency graph. Tell the user which manifest pulls it in; " +
+			"adding it again is unnecessary."
+		if out.Stdlib != nil {
+			out.Guidance += fmt.Sprintf(" Also mention that %s could replace it (%s).", out.Stdlib.Replacement, out.Stdlib.Since)
+		}
+	case out.Stdlib != nil:
+		out.Verdict = VerdictStdlib
+		out.CoveredBy = same
+		out.Reason = fmt.Sprintf("stdlib covers this: use %s (%s); %s", out.Stdlib.Replacement, out.Stdlib.Since, out.Stdlib.Note)
+		out.Guidance = fmt.Sprintf("IMPORTANT: Do NOT add %s. Use %s from the standard library instead, "+
+			"after checking that the project's minimum supported version is at least %s. "+
+			"Only add the package if the user confirms the standard library cannot do the job.",
+			candidate, out.Stdlib.Replacement, out.Stdlib.Since)
+	case len(same) > 0:
+		out.Verdict = VerdictCovered
+		out.CoveredBy = same
+		out.Reason = fmt.Sprintf("already covered by %s (%s)", coverageNames(same), capability.Name)
+		out.Guidance = fmt.Sprintf("IMPORTANT: Do NOT add %s. The project already uses %s for %s; use it instead. "+
+			"Two libraries for the same job double the maintenance, security exposure, and build time. "+
+			"Only add the package if the user explicitly approves it after hearing this.",
+			candidate, coverageNames(same), strings.ToLower(capability.Name))
+	case capability != nil:
+		out.Verdict = VerdictNew
+		out.Reason = fmt.Sprintf("new capability: nothing in the project provides %s yet", strings.ToLower(capability.Name))
+		out.Guidance = "No existing dependency or standard library package covers this capability. " +
+			"Before adding it, confirm with the user that the need is real and consider the package's maintenance, license, and size."
+	default:
+		out.Verdict = VerdictNew
+		out.Reason = "new capability: the package is not in mtb's taxonomy and the project does not depend on it"
+		out.Guidance = "mtb does not know what this package does, so it could not compare it with existing dependencies. " +
+			"Check the project's dependencies (the deps tool lists them) and the standard library for something that already does the job " +
+			"before adding it, and confirm the addition with the user."
+	}
+	return out
+}
+
+// lookupPackage runs lookup on a package and, for Go, on each parent import
+// path in turn, so that a package inside a module such as
+// github.com/stretchr/testify/assert is found under its module.
+func lookupPackage[T any](ecosystem, name string, lookup func(ecosystem, name string) (*T, bool)) (*T, bool) {
+	for {
+		if v, ok := lookup(ecosystem, name); ok {
+			return v, true
+		}
+		i := strings.LastIndex(name, "/")
+		if ecosystem != EcosystemGo || i < 0 {
+			return nil, false
+		}
+		name = name[:i]
+	}
+}
+
+// providesPackage reports whether depending on dep already provides the
+// candidate package: the names match, or, for Go, the candidate is a
+// package inside the dep module.
+func providesPackage(ecosystem, dep, candidate string) bool {
+	d, c := normalizePackage(ecosystem, dep), normalizePackage(ecosystem, candidate)
+	return d == c || ecosystem == EcosystemGo && strings.HasPrefix(c, d+"/")
+}
+
+// inferEcosystem returns the candidate's ecosystem: the one given, Go for
+// module paths, the project's only non-Go ecosystem, or the only ecosystem
+// whose taxonomy lists the package.
+func inferEcosystem(candidate, given string, deps []Dependency) (string, error) {
+	ecosystems := []string{EcosystemGo, EcosystemNPM, EcosystemPyPI, EcosystemCargo}
+	if given != "" {
+		for _, e := range ecosystems {
+			if given == e {
+				return given, nil
+			}
+		}
+		return "", fmt.Errorf("unknown ecosystem %q: use go, npm, pypi, or cargo", given)
+	}
+	if first, _, ok := strings.Cut(candidate, "/"); ok && strings.Contains(first, ".") {
+		return EcosystemGo, nil
+	}
+
+	used := map[string]bool{}
+	for _, d := range deps {
+		if d.Ecosystem != EcosystemGo {
+			used[d.Ecosystem] = true
+		}
+	}
+	if len(used) == 1 {
+		for e := range used {
+			return e, nil
+		}
+	}
+
+	var known []string
+	for _, e := range ecosystems[1:] {
+		_, isCapability := lookupCapability(e, candidate)
+		_, isStdlib := lookupStdlib(e, candidate)
+		if (isCapability || isStdlib) && (len(used) == 0 || used[e]) {
+			known = append(known, e)
+		}
+	}
+	if len(known) == 1 {
+		return known[0], nil
+	}
+	return "", fmt.Errorf("cannot tell which ecosystem %q belongs to: set ecosystem to go, npm, pypi, or cargo", candidate)
+}
+
+// sortCoverage puts direct dependencies first, then orders by name and
+// manifest.
+func sortCoverage(deps []Dependency) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].Direct != deps[j].Direct {
+			return deps[i].Direct
+		}
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].Manifest < deps[j].Manifest
+	})
+}
+
+// coverageNames lists the distinct package names in deps.
+func coverageNames(deps []Dependency) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, d := range deps {
+		if !seen[d.Name] {
+			seen[d.Name] = true
+			names = append(names, d.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestCheckDependency(t *testing.T) {
+	deps := []Dependency{
+		{Ecosystem: EcosystemGo, Name: "github.com/sirupsen/logrus", Direct: true, Manifest: "go.mod"},
+		{Ecosystem: EcosystemGo, Name: "github.com/stretchr/testify", Direct: true, Manifest: "go.mod"},
+		{Ecosystem: EcosystemGo, Name: "github.com/google/uuid", Direct: false, Manifest: "go.mod"},
+		{Ecosystem: EcosystemNPM, Name: "axios", Direct: true, Manifest: "web/package.json"},
+	}
+	cases := []struct {
+		ecosystem, candidate string
+		verdict, coveredBy   string
+	}{
+		// The candidate or the module containing it is already required.
+		{EcosystemGo, "github.com/stretchr/testify/assert", VerdictCovered, "github.com/stretchr/testify"},
+		{EcosystemGo, "github.com/google/uuid", VerdictCovered, "github.com/google/uuid"},
+		// The standard library beats another dependency.
+		{EcosystemGo, "github.com/pkg/errors", VerdictStdlib, ""},
+		{EcosystemGo, "golang.org/x/exp/slices", VerdictStdlib, ""},
+		{EcosystemGo, "go.uber.org/zap", VerdictCovered, "github.com/sirupsen/logrus"},
+		{EcosystemGo, "github.com/oklog/ulid/v2", VerdictCovered, "github.com/google/uuid"},
+		{EcosystemNPM, "got", VerdictCovered, "axios"},
+		{EcosystemNPM, "node-fetch", VerdictStdlib, "axios"},
+		{EcosystemGo, "github.com/spf13/cobra", VerdictNew, ""},
+		{EcosystemGo, "example.com/unknown", VerdictNew, ""},
+		// Ecosystems do not cover each other.
+		{EcosystemPyPI, "httpx", VerdictNew, ""},
+	}
+	for _, c := range cases {
+		out := checkDependency(c.candidate, c.ecosystem, deps)
+		got := ""
+		if len(out.CoveredBy) > 0 {
+			got = out.CoveredBy[0].Name
+		}
+		if out.Verdict != c.verdict || got != c.coveredBy {
+			t.Errorf("checkDependency(%s) = %s covered by %q, want %s covered by %q (%s)",
+				c.candidate, out.Verdict, got, c.verdict, c.coveredBy, out.Reason)
+		}
+		if out.Reason == "" || out.Guidance == "" {
+			t.Errorf("checkDependency(%s) has no reason or guidance", c.candidate)
+		}
+	}
+}
+
+func TestInferEcosystem(t *testing.T) {
+	npm := []Dependency{{Ecosystem: EcosystemNPM, Name: "express"}}
+	mixed := []Dependency{{Ecosystem: EcosystemNPM, Name: "express"}, {Ecosystem: EcosystemPyPI, Name: "flask"}}
+	cases := []struct {
+		candidate, given string
+		deps             []Dependency
+		want             string
+	}{
+		{"github.com/pkg/errors", "", nil, EcosystemGo},
+		{"left-pad", "", npm, EcosystemNPM},
+		{"httpx", "", nil, EcosystemPyPI},
+		{"httpx", "", mixed, EcosystemPyPI},
+		{"serde_json", "PyPI", nil, EcosystemPyPI},
+		{"uuid", "", nil, ""},
+		{"left-pad", "", mixed, ""},
+		{"x", "maven", nil, ""},
+	}
+	for _, c := range cases {
+		got, err := inferEcosystem(c.candidate, strings.ToLower(c.given), c.deps)
+		if got != c.want || (err != nil) != (c.want == "") {
+			t.Errorf("inferEcosystem(%q, %q) = %q, %v; want %q", c.candidate, c.given, got, err, c.want)
+		}
+	}
+}
+
+func TestHandleCheckDependency(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\nrequire github.com/rs/zerolog v1.33.0\n",
+	})
+
+	_, out, err := HandleCheckDependency(context.Background(), &mcp.CallToolRequest{}, CheckDependencyInput{
+		Path:    dir,
+		Package: "github.com/sirupsen/logrus",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Ecosystem != EcosystemGo || out.Verdict != VerdictStdlib || out.Stdlib.Replacement != "log/slog" {
+		t.Errorf("unexpected result: %+v", out)
+	}
+	if len(out.CoveredBy) != 1 || out.CoveredBy[0].Name != "github.com/rs/zerolog" {
+		t.Errorf("expected zerolog to be listed as covering logging, got %+v", out.CoveredBy)
+	}
+
+	res, _, _ := HandleCheckDependency(context.Background(), &mcp.CallToolRequest{}, CheckDependencyInput{Path: dir})
+	if !res.IsError {
+		t.Error("expected an error without a package")
+	}
+}
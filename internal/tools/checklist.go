@@ -11,12 +11,23 @@ import (
 
 type ChecklistInput struct {
 	Project string `json:"project" jsonschema:"description of the project being evaluated"`
+	Path    string `json:"path,omitempty" jsonschema:"project directory to scan for evidence such as CI configs, probes, and runbooks"`
 }
 
 type ChecklistItem struct {
 	Category    string `json:"category"`
 	Question    string `json:"question"`
 	Description string `json:"description"`
+	// Status and Evidence are set when a path is scanned: Status is
+	// "detected", "partial", or "not detected", and Evidence lists the
+	// supporting files relative to the path.
+	Status   string   `json:"status,omitempty"`
+	Evidence []string `json:"evidence,omitempty"`
+	// Answer and Notes are the user's response when the client supports
+	// elicitation: Answer is "addressed", "partially addressed", "not
+	// addressed", or "not needed".
+	Answer string `json:"answer,omitempty"`
+	Notes  string `json:"notes,omitempty"`
 }
 
 type ChecklistOutput struct {
@@ -62,17 +73,50 @@ func HandleChecklist(ctx context.Context, req *mcp.CallToolRequest, input Checkl
 		},
 	}
 
+	cfg, err := LoadConfig(input.Path)
+	if err != nil {
+		return ErrResult[ChecklistOutput](err.Error())
+	}
+	items = append(items, cfg.Checklist.Items...)
+
 	guidance := fmt.Sprintf("IMPORTANT: Present each checklist item below to the user for project %q and wait for their answers. "+
 		"Do NOT skip items or assume answers. The goal is to identify operational gaps before they become incidents. "+
 		"For each item, ask the user whether it is addressed, partially addressed, or not addressed, "+
 		"and discuss what concrete next steps would close the gap.", input.Project)
 
+	if input.Path != "" {
+		if err := detectEvidence(input.Path, items); err != nil {
+			return ErrResult[ChecklistOutput]("evidence scan failed: " + err.Error())
+		}
+		guidance += fmt.Sprintf(" Each item has a detected status based on files found in %q. "+
+			"Present the supporting files for detected and partial items and ask the user to confirm they actually cover the concern; "+
+			"files are evidence, not proof. Focus the discussion on items that were not detected.", input.Path)
+	}
+
+	if canElicit(req) {
+		elicited := elicitChecklist(ctx, req.Session, input.Project, items)
+		switch {
+		case elicited.completed():
+			guidance = fmt.Sprintf("The user reviewed each checklist item for project %q directly; their answers and notes are on the items. "+
+				"Do NOT ask them again. Discuss concrete next steps for the items that are not or only partially addressed.", input.Project)
+		case elicited.Declined:
+			guidance += " The user declined to review the checklist in a form; do NOT answer the items on their behalf."
+		case elicited.Err != nil:
+			guidance += fmt.Sprintf(" NOTE: Asking the user directly failed (%v).", elicited.Err)
+		}
+	}
+
 	output := ChecklistOutput{
 		Items:    items,
 		Guidance: guidance,
 	}
 
 	summary := fmt.Sprintf("Operational readiness checklist for: %q\nGenerated %d items to evaluate.", input.Project, len(items))
+	for _, item := range items {
+		if item.Status != "" {
+			summary += fmt.Sprintf("\n- %s: %s", item.Category, item.Status)
+		}
+	}
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
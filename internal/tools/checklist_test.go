@@ -55,3 +55,42 @@ func TestHandleChecklist_ItemStructure(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleChecklist_WithPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".github/workflows/ci.yml": "jobs:\n  test:\n    steps:\n      - run: go test ./...\n",
+		"main_test.go":             "package main\n",
+	})
+
+	_, output, err := HandleChecklist(context.Background(), &mcp.CallToolRequest{}, ChecklistInput{
+		Project: "internal billing service",
+		Path:    dir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, item := range output.Items {
+		if item.Status == "" {
+			t.Errorf("%s: expected a detected status", item.Category)
+		}
+	}
+	if output.Items[0].Status != StatusDetected || len(output.Items[0].Evidence) != 2 {
+		t.Errorf("expected CI to be detected from the workflow and test file, got %+v", output.Items[0])
+	}
+}
+
+func TestHandleChecklist_WithoutPathHasNoStatus(t *testing.T) {
+	_, output, err := HandleChecklist(context.Background(), &mcp.CallToolRequest{}, ChecklistInput{
+		Project: "internal billing service",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, item := range output.Items {
+		if item.Status != "" || item.Evidence != nil {
+			t.Errorf("%s: expected no evidence without a path", item.Category)
+		}
+	}
+}
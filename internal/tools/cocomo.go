@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/boyter/scc/v3/processor"
+)
+
+// CocomoModel is the basic COCOMO model behind cost estimates. Results echo
+// the model they were estimated with so the numbers can be reproduced.
+type CocomoModel struct {
+	// ProjectType is organic, semi-detached, or embedded.
+	ProjectType string `json:"projectType" yaml:"project_type"`
+	// AverageWage is a developer's average annual wage.
+	AverageWage int64 `json:"averageWage" yaml:"average_wage"`
+	// Overhead multiplies wages to cover facilities, equipment, and the
+	// like.
+	Overhead float64 `json:"overhead" yaml:"overhead"`
+	// EAF is the effort adjustment factor, 1.0 when every cost driver is
+	// rated nominal.
+	EAF float64 `json:"eaf" yaml:"eaf"`
+	// Currency is the symbol costs are shown with, e.g. $ or €.
+	Currency string `json:"currency" yaml:"currency"`
+}
+
+// cocomoProjectTypes mirrors scc's coefficients for each project type:
+// effort a and b, then schedule c and d.
+var cocomoProjectTypes = map[string][4]float64{
+	"organic":       {2.4, 1.05, 2.5, 0.38},
+	"semi-detached": {3.0, 1.12, 2.5, 0.35},
+	"embedded":      {3.6, 1.20, 2.5, 0.32},
+}
+
+// defaultCocomo is scc's default model.
+func defaultCocomo() CocomoModel {
+	return CocomoModel{
+		ProjectType: "organic",
+		AverageWage: processor.AverageWage,
+		Overhead:    processor.Overhead,
+		EAF:         processor.EAF,
+		Currency:    "$",
+	}
+}
+
+// resolveCocomo fills the model from input, then the configured model, then
+// scc's defaults, and validates the result.
+func resolveCocomo(input, configured CocomoModel) (CocomoModel, error) {
+	m := defaultCocomo()
+	for _, layer := range []CocomoModel{configured, input} {
+		if layer.ProjectType != "" {
+			m.ProjectType = strings.ToLower(layer.ProjectType)
+		}
+		if layer.AverageWage != 0 {
+			m.AverageWage = layer.AverageWage
+		}
+		if layer.Overhead != 0 {
+			m.Overhead = layer.Overhead
+		}
+		if layer.EAF != 0 {
+			m.EAF = layer.EAF
+		}
+		if layer.Currency != "" {
+			m.Currency = layer.Currency
+		}
+	}
+	if _, ok := cocomoProjectTypes[m.ProjectType]; !ok {
+		return m, fmt.Errorf("unknown COCOMO project type %q: use organic, semi-detached, or embedded", m.ProjectType)
+	}
+	if m.AverageWage < 0 || m.Overhead < 0 || m.EAF < 0 {
+		return m, fmt.Errorf("COCOMO average wage, overhead, and EAF must be positive")
+	}
+	return m, nil
+}
+
+// cost estimates the cost of sloc lines of code the way scc does.
+func (m CocomoModel) cost(sloc int64) float64 {
+	if sloc == 0 {
+		return 0
+	}
+	k := cocomoProjectTypes[m.ProjectType]
+	effort := k[0] * math.Pow(float64(sloc)/1000, k[1]) * m.EAF
+	return effort * float64(m.AverageWage/12) * m.Overhead
+}
+
+// String describes the model, e.g. "organic COCOMO, $56,286/year, overhead
+// 2.40, EAF 1.00".
+func (m CocomoModel) String() string {
+	return fmt.Sprintf("%s COCOMO, %s/year, overhead %.2f, EAF %.2f",
+		m.ProjectType, FormatMoney(float64(m.AverageWage), m.Currency), m.Overhead, m.EAF)
+}
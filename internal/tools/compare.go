@@ -5,47 +5,298 @@ package tools
 import (
 	"context"
 	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type CompareInput struct {
-	Project string `json:"project" jsonschema:"description of the project being evaluated"`
+	Project           string   `json:"project" jsonschema:"description of the project being evaluated"`
+	Path              string   `json:"path,omitempty" jsonschema:"directory inside the git repository to compare (default .)"`
+	Base              string   `json:"base,omitempty" jsonschema:"git ref for the before side (default HEAD)"`
+	Head              string   `json:"head,omitempty" jsonschema:"git ref for the after side (default: the working tree)"`
+	BaseSnapshot      string   `json:"base_snapshot,omitempty" jsonschema:"name of a saved snapshot to use as the before side instead of a git ref"`
+	ExcludeDir        []string `json:"exclude_dir,omitempty" jsonschema:"directories to exclude from analysis"`
+	ExcludeExtensions []string `json:"exclude_ext,omitempty" jsonschema:"file extensions to exclude (e.g. min.js)"`
+	IncludeExtensions []string `json:"include_ext,omitempty" jsonschema:"only include these file extensions"`
+}
+
+// CountDelta is a before/after pair for an integer metric.
+type CountDelta struct {
+	Before int64 `json:"before"`
+	After  int64 `json:"after"`
+	Delta  int64 `json:"delta"`
+}
+
+// CostDelta is a before/after pair for an estimated cost.
+type CostDelta struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+}
+
+type LanguageDelta struct {
+	Name          string     `json:"name"`
+	Code          CountDelta `json:"code"`
+	Complexity    CountDelta `json:"complexity"`
+	Lines         CountDelta `json:"lines"`
+	EstimatedCost CostDelta  `json:"estimatedCost"`
 }
 
 type CompareOutput struct {
-	Guidance string `json:"guidance"`
+	Base      string          `json:"base"`
+	Head      string          `json:"head"`
+	Languages []LanguageDelta `json:"languages"`
+	Total     LanguageDelta   `json:"total"`
+	// Cocomo is the model both sides' costs were estimated with.
+	Cocomo   CocomoModel `json:"cocomo"`
+	Table    string      `json:"table"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Guidance string      `json:"guidance"`
 }
 
+// workingTree labels the head side when no head ref is given.
+const workingTree = "working tree"
+
 func HandleCompare(ctx context.Context, req *mcp.CallToolRequest, input CompareInput) (*mcp.CallToolResult, CompareOutput, error) {
 	if input.Project == "" {
 		return ErrResult[CompareOutput]("project is required")
 	}
 
-	guidance := fmt.Sprintf(`IMPORTANT: You must measure the complexity impact of your changes to %q using the stats tool. Follow these steps:
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[CompareOutput]("invalid path: " + err.Error())
+	}
+	if input.BaseSnapshot != "" && input.Base != "" {
+		return ErrResult[CompareOutput]("base and base_snapshot are mutually exclusive")
+	}
 
-1. **Get a baseline.** Run stats on the project BEFORE your changes take effect. You can either:
-   - Use stats output you already captured at the start of this task, OR
-   - Check out the prior revision (e.g. git stash, git checkout HEAD~1) and run stats, then restore your changes.
+	base := input.Base
+	if base == "" {
+		base = "HEAD"
+	}
+	head := input.Head
+	if head == "" {
+		head = workingTree
+	}
 
-2. **Get the current state.** Run stats on the project WITH your changes applied.
+	// A snapshot against the working tree needs no git history.
+	if input.BaseSnapshot == "" || head != workingTree {
+		if _, err := runGit(ctx, absPath, "rev-parse", "--show-toplevel"); err != nil {
+			return ErrResult[CompareOutput]("compare requires a git repository: " + err.Error())
+		}
+	}
 
-3. **Compare the results.** Present a before/after table to the user showing:
-   - Lines of code (delta)
-   - Complexity score (delta)
-   - Estimated cost (delta)
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[CompareOutput](err.Error())
+	}
+	// A snapshot's filters take precedence over the config so both sides
+	// count the same files.
+	defaults := SnapshotFilters{
+		ExcludeDir:        cfg.Stats.ExcludeDir,
+		ExcludeExtensions: cfg.Stats.ExcludeExtensions,
+		IncludeExtensions: cfg.Stats.IncludeExtensions,
+	}
+	var snapshot *Snapshot
+	if input.BaseSnapshot != "" {
+		if snapshot, err = loadSnapshot(ctx, absPath, input.BaseSnapshot); err != nil {
+			return ErrResult[CompareOutput](err.Error())
+		}
+		defaults = snapshot.Filters
+		base = "snapshot " + snapshot.Name
+	}
+	model, err := resolveCocomo(CocomoModel{}, cfg.Cocomo)
+	if err != nil {
+		return ErrResult[CompareOutput](err.Error())
+	}
+	excludeDir := orDefault(input.ExcludeDir, defaults.ExcludeDir)
+	excludeExt := orDefault(input.ExcludeExtensions, defaults.ExcludeExtensions)
+	includeExt := orDefault(input.IncludeExtensions, defaults.IncludeExtensions)
 
-4. **Discuss the delta.** Ask the user whether the added complexity is justified given what was accomplished. If complexity went up significantly, flag it and discuss whether the change can be simplified.
+	analyze := func(ref string) (*StatsOutput, error) {
+		return analyzeRef(ctx, absPath, ref, model, excludeDir, excludeExt, includeExt)
+	}
 
-Do NOT skip this process or assume the changes are acceptable. The user must see the numbers and make an informed decision.`, input.Project)
+	// The two sides are independent, so analyze them at the same time.
+	var before, after *StatsOutput
+	var beforeErr, afterErr error
+	var wg sync.WaitGroup
+	if snapshot != nil {
+		before = &snapshot.Stats
+	} else {
+		wg.Go(func() { before, beforeErr = analyze(base) })
+	}
+	wg.Go(func() { after, afterErr = analyze(head) })
+	wg.Wait()
+	if beforeErr != nil {
+		return ErrResult[CompareOutput]("analysis of " + base + " failed: " + beforeErr.Error())
+	}
+	if afterErr != nil {
+		return ErrResult[CompareOutput]("analysis of " + head + " failed: " + afterErr.Error())
+	}
 
-	output := CompareOutput{
-		Guidance: guidance,
+	output := diffStats(before, after, model)
+	output.Base = base
+	output.Head = head
+	output.Table = renderDeltaTable(output)
+	if snapshot != nil {
+		output.Warnings = append(output.Warnings, baselineWarnings(ctx, snapshot, absPath, model)...)
+	}
+	if limit := cfg.Thresholds.ComplexityDelta; limit > 0 && output.Total.Complexity.Delta > limit {
+		output.Warnings = append(output.Warnings, fmt.Sprintf("complexity grew by %d, above the project threshold of %d", output.Total.Complexity.Delta, limit))
+	}
+	output.Guidance = fmt.Sprintf("IMPORTANT: Present the before/after table for %q to the user. "+
+		"Ask whether the added complexity is justified given what was accomplished. "+
+		"If complexity went up significantly, flag it and discuss whether the change can be simplified. "+
+		"Do NOT assume the changes are acceptable — the user must see the numbers and make an informed decision.", input.Project)
+	for _, w := range output.Warnings {
+		output.Guidance += " WARNING: " + w + "."
 	}
 
-	summary := fmt.Sprintf("Compare complexity impact for: %q\nRun stats before and after changes, then present the delta.", input.Project)
+	summary := fmt.Sprintf("Complexity impact for %q (%s → %s):\n\n%s", input.Project, base, head, output.Table)
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
 	}, output, nil
 }
+
+// analyzeRef runs scc on absPath as of ref, which is a git tree-ish or
+// workingTree.
+func analyzeRef(ctx context.Context, absPath, ref string, model CocomoModel, excludeDir, excludeExt, includeExt []string) (*StatsOutput, error) {
+	dir := absPath
+	if ref != workingTree {
+		tree, cleanup, err := exportTree(ctx, absPath, ref)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		dir = tree
+	}
+	return RunSCC(ctx, dir, &model, true, excludeDir, excludeExt, includeExt)
+}
+
+// diffStats computes per-language and total deltas between two analyses.
+// Languages present on only one side are reported with zeros on the other.
+// Per-language costs are estimated with model.
+func diffStats(before, after *StatsOutput, model CocomoModel) CompareOutput {
+	byName := map[string]*LanguageDelta{}
+	var names []string
+	get := func(name string) *LanguageDelta {
+		d, ok := byName[name]
+		if !ok {
+			d = &LanguageDelta{Name: name}
+			byName[name] = d
+			names = append(names, name)
+		}
+		return d
+	}
+
+	for _, l := range before.LanguageSummary {
+		d := get(l.Name)
+		d.Code.Before, d.Complexity.Before, d.Lines.Before = l.Code, l.Complexity, l.Lines
+	}
+	for _, l := range after.LanguageSummary {
+		d := get(l.Name)
+		d.Code.After, d.Complexity.After, d.Lines.After = l.Code, l.Complexity, l.Lines
+	}
+
+	output := CompareOutput{Cocomo: model}
+	output.Total.Name = "Total"
+	for _, name := range names {
+		d := byName[name]
+		d.EstimatedCost.Before = model.cost(d.Code.Before)
+		d.EstimatedCost.After = model.cost(d.Code.After)
+		finishDelta(d)
+		output.Total.Code.Before += d.Code.Before
+		output.Total.Code.After += d.Code.After
+		output.Total.Complexity.Before += d.Complexity.Before
+		output.Total.Complexity.After += d.Complexity.After
+		output.Total.Lines.Before += d.Lines.Before
+		output.Total.Lines.After += d.Lines.After
+		output.Languages = append(output.Languages, *d)
+	}
+	// COCOMO is non-linear, so the project total comes from scc rather than
+	// the sum of the per-language estimates.
+	output.Total.EstimatedCost.Before = before.EstimatedCost
+	output.Total.EstimatedCost.After = after.EstimatedCost
+	finishDelta(&output.Total)
+
+	sort.SliceStable(output.Languages, func(i, j int) bool {
+		a, b := output.Languages[i], output.Languages[j]
+		if abs(a.Code.Delta) != abs(b.Code.Delta) {
+			return abs(a.Code.Delta) > abs(b.Code.Delta)
+		}
+		return a.Name < b.Name
+	})
+
+	return output
+}
+
+func finishDelta(d *LanguageDelta) {
+	d.Code.Delta = d.Code.After - d.Code.Before
+	d.Complexity.Delta = d.Complexity.After - d.Complexity.Before
+	d.Lines.Delta = d.Lines.After - d.Lines.Before
+	d.EstimatedCost.Delta = d.EstimatedCost.After - d.EstimatedCost.Before
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// renderDeltaTable renders a markdown table of before → after (delta) for
+// each language, followed by the project total.
+func renderDeltaTable(output CompareOutput) string {
+	var b strings.Builder
+	b.WriteString("| Language | Code | Complexity | Lines | Est. cost |\n")
+	b.WriteString("|----------|------|------------|-------|-----------|\n")
+	row := func(name string, d LanguageDelta) {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", name,
+			formatCountDelta(d.Code), formatCountDelta(d.Complexity), formatCountDelta(d.Lines), formatCostDelta(d.EstimatedCost, output.Cocomo.Currency))
+	}
+	for _, d := range output.Languages {
+		row(d.Name, d)
+	}
+	row("**Total**", output.Total)
+	return b.String()
+}
+
+func formatCountDelta(d CountDelta) string {
+	return fmt.Sprintf("%d → %d (%+d)", d.Before, d.After, d.Delta)
+}
+
+func formatCostDelta(d CostDelta, currency string) string {
+	sign := "+"
+	if d.Delta < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s → %s (%s%s)", FormatMoney(d.Before, currency), FormatMoney(d.After, currency), sign, FormatMoney(math.Abs(d.Delta), currency))
+}
+
+// FormatMoney renders whole units of currency with thousands separators,
+// e.g. $37,395.
+func FormatMoney(v float64, currency string) string {
+	s := fmt.Sprintf("%.0f", math.Round(math.Abs(v)))
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if v < 0 {
+		return "-" + currency + b.String()
+	}
+	return currency + b.String()
+}
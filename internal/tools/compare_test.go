@@ -4,6 +4,7 @@ package tools
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -20,8 +21,11 @@ func TestHandleCompare_EmptyProject(t *testing.T) {
 }
 
 func TestHandleCompare_ValidProject(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+
 	_, output, err := HandleCompare(context.Background(), &mcp.CallToolRequest{}, CompareInput{
 		Project: "internal billing service",
+		Path:    dir,
 	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -30,3 +34,98 @@ func TestHandleCompare_ValidProject(t *testing.T) {
 		t.Fatal("expected guidance to be non-empty")
 	}
 }
+
+func TestHandleCompare_NotGitRepo(t *testing.T) {
+	result, _, err := HandleCompare(context.Background(), &mcp.CallToolRequest{}, CompareInput{
+		Project: "scratch",
+		Path:    t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || !result.IsError {
+		t.Fatal("expected error result outside a git repository")
+	}
+}
+
+func TestHandleCompare_WorkingTreeDelta(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\tif true {\n\t\tprintln(1)\n\t}\n}\n",
+	})
+
+	_, output, err := HandleCompare(context.Background(), &mcp.CallToolRequest{}, CompareInput{
+		Project: "scratch",
+		Path:    dir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output.Base != "HEAD" || output.Head != workingTree {
+		t.Fatalf("expected HEAD → working tree, got %s → %s", output.Base, output.Head)
+	}
+	if len(output.Languages) != 1 || output.Languages[0].Name != "Go" {
+		t.Fatalf("expected a single Go delta, got %+v", output.Languages)
+	}
+	goDelta := output.Languages[0]
+	if goDelta.Code.Delta != 3 {
+		t.Errorf("expected +3 code lines, got %+d", goDelta.Code.Delta)
+	}
+	if goDelta.Complexity.Delta <= 0 {
+		t.Errorf("expected complexity to increase, got %+d", goDelta.Complexity.Delta)
+	}
+	if output.Total.EstimatedCost.Delta <= 0 {
+		t.Errorf("expected cost to increase, got %f", output.Total.EstimatedCost.Delta)
+	}
+	if !strings.Contains(output.Table, "| Go |") || !strings.Contains(output.Table, "**Total**") {
+		t.Errorf("expected markdown table with Go and total rows, got:\n%s", output.Table)
+	}
+}
+
+func TestHandleCompare_BetweenRefs(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	writeFiles(t, dir, map[string]string{"style.css": "body { color: red; }\n"})
+	gitCommitAll(t, dir, "add css")
+	// Uncommitted changes must not affect a ref-to-ref comparison.
+	writeFiles(t, dir, map[string]string{"extra.py": "print(1)\n"})
+
+	_, output, err := HandleCompare(context.Background(), &mcp.CallToolRequest{}, CompareInput{
+		Project: "scratch",
+		Path:    dir,
+		Base:    "HEAD~1",
+		Head:    "HEAD",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, d := range output.Languages {
+		switch d.Name {
+		case "CSS":
+			if d.Code.Before != 0 || d.Code.After != 1 {
+				t.Errorf("expected CSS 0 → 1, got %d → %d", d.Code.Before, d.Code.After)
+			}
+		case "Python":
+			t.Error("expected uncommitted Python file to be ignored")
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	cases := map[float64]string{
+		0:       "$0",
+		999.6:   "$1,000",
+		37395.2: "$37,395",
+		-5956:   "-$5,956",
+		1234567: "$1,234,567",
+	}
+	for in, want := range cases {
+		if got := FormatMoney(in, "$"); got != want {
+			t.Errorf("FormatMoney(%v) = %q, want %q", in, got, want)
+		}
+	}
+	if got := FormatMoney(-1234, "€"); got != "-€1,234" {
+		t.Errorf("expected the currency symbol, got %q", got)
+	}
+}
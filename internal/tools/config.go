@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configNames are the project configuration file names, in lookup order.
+var configNames = []string{".mtb.yaml", ".mtb.yml"}
+
+// Config is a project's .mtb.yaml. Every setting is a default; explicit tool
+// inputs take precedence.
+type Config struct {
+	Stats      StatsConfig      `yaml:"stats"`
+	Consult    ConsultConfig    `yaml:"consult"`
+	Checklist  ChecklistConfig  `yaml:"checklist"`
+	Thresholds ThresholdsConfig `yaml:"thresholds"`
+	Budget     BudgetConfig     `yaml:"budget"`
+	ADR        ADRConfig        `yaml:"adr"`
+	Hotspots   HotspotsConfig   `yaml:"hotspots"`
+	Preview    PreviewConfig    `yaml:"preview"`
+	Cocomo     CocomoModel      `yaml:"cocomo"`
+
+	// File is the path the configuration was loaded from, or empty when no
+	// configuration file was found.
+	File string `yaml:"-"`
+}
+
+// StatsConfig holds default scc filters for stats, compare, hotspots, and
+// trend.
+type StatsConfig struct {
+	ExcludeDir        []string `yaml:"exclude_dir"`
+	ExcludeExtensions []string `yaml:"exclude_ext"`
+	IncludeExtensions []string `yaml:"include_ext"`
+}
+
+// ConsultConfig customizes the consult questions. Occurrences of {problem}
+// in a question are replaced with the problem description.
+type ConsultConfig struct {
+	Questions []string `yaml:"questions"`
+	// ReplaceQuestions drops the built-in questions in favor of Questions.
+	ReplaceQuestions bool         `yaml:"replace_questions"`
+	Search           SearchConfig `yaml:"search"`
+}
+
+// SearchConfig sets where consult searches for existing repositories.
+type SearchConfig struct {
+	// Provider is "offline", the default, or "github".
+	Provider string `yaml:"provider"`
+	// URL is the GitHub API to search, e.g.
+	// https://github.example.com/api/v3 (default https://api.github.com).
+	URL string `yaml:"url"`
+}
+
+// ChecklistConfig adds project-specific checklist categories.
+type ChecklistConfig struct {
+	Items []ChecklistItem `yaml:"items"`
+}
+
+// ThresholdsConfig sets complexity levels that produce warnings. Zero
+// disables a threshold.
+type ThresholdsConfig struct {
+	// FileComplexity flags files in stats whose complexity exceeds it.
+	FileComplexity int64 `yaml:"file_complexity"`
+	// ComplexityDelta flags comparisons whose total complexity grows by
+	// more than it.
+	ComplexityDelta int64 `yaml:"complexity_delta"`
+}
+
+// Budget caps how much a single commit may grow a metric. Zero means no
+// limit.
+type Budget struct {
+	Complexity int64   `yaml:"complexity" json:"complexity,omitempty"`
+	Code       int64   `yaml:"code" json:"code,omitempty"`
+	Cost       float64 `yaml:"cost" json:"cost,omitempty"`
+}
+
+// BudgetConfig holds the budgets the gate enforces on staged changes.
+// Languages listed under Languages are budgeted on their own and left out of
+// the project-wide budget.
+type BudgetConfig struct {
+	Budget    `yaml:",inline"`
+	Languages map[string]Budget `yaml:"languages" json:"languages,omitempty"`
+}
+
+// ADRConfig sets where and how the adr tool writes decision records.
+type ADRConfig struct {
+	// Dir is relative to the repository root.
+	Dir string `yaml:"dir"`
+	// Format is "madr" or "nygard".
+	Format string `yaml:"format"`
+}
+
+// HotspotsConfig sets the default history window for hotspots.
+type HotspotsConfig struct {
+	// Since is a git date, e.g. "6 months ago" or "2024-01-01".
+	Since string `yaml:"since"`
+}
+
+// PreviewConfig sets where preview_dependency resolves modules.
+type PreviewConfig struct {
+	// GoProxy is a GOPROXY value, e.g. "file:///srv/goproxy" to work
+	// offline from a mirror. The default, "off", uses the module cache only.
+	GoProxy string `yaml:"goproxy"`
+}
+
+// LoadConfig finds the nearest .mtb.yaml by walking up from path and
+// parses it. It returns an empty Config when there is none.
+func LoadConfig(path string) (*Config, error) {
+	file, err := findConfig(path)
+	if err != nil || file == "" {
+		return &Config{}, err
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", file, err)
+	}
+	cfg.File = file
+	return &cfg, nil
+}
+
+// findConfig returns the first configuration file found in path's
+// directory or any of its parents, or "" if there is none.
+func findConfig(path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		for _, name := range configNames {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// orDefault returns input unless it is empty, in which case it returns the
+// configured default.
+func orDefault(input, configured []string) []string {
+	if len(input) > 0 {
+		return input
+	}
+	return configured
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestLoadConfig_None(t *testing.T) {
+	cfg, err := LoadConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.File != "" {
+		t.Fatalf("expected no config file, got %s", cfg.File)
+	}
+}
+
+func TestLoadConfig_WalksUp(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".mtb.yaml":     "stats:\n  exclude_dir: [generated]\nthresholds:\n  file_complexity: 10\n",
+		"svc/a/main.go": "package main\n",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "svc", "a", "main.go"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.File != filepath.Join(dir, ".mtb.yaml") {
+		t.Fatalf("expected config from repo root, got %q", cfg.File)
+	}
+	if len(cfg.Stats.ExcludeDir) != 1 || cfg.Stats.ExcludeDir[0] != "generated" || cfg.Thresholds.FileComplexity != 10 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestLoadConfig_NearestWins(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".mtb.yaml":    "stats:\n  exclude_dir: [root]\n",
+		"svc/.mtb.yml": "stats:\n  exclude_dir: [svc]\n",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "svc"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Stats.ExcludeDir[0] != "svc" {
+		t.Fatalf("expected the nearest config to win, got %v", cfg.Stats.ExcludeDir)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{".mtb.yaml": "stats:\n  exclude_dirs: [typo]\n"})
+
+	if _, err := LoadConfig(dir); err == nil || !strings.Contains(err.Error(), ".mtb.yaml") {
+		t.Fatalf("expected an error naming the config file for an unknown key, got %v", err)
+	}
+}
+
+func TestConfig_StatsFiltersAndThreshold(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".mtb.yaml": "stats:\n  exclude_ext: [css]\nthresholds:\n  file_complexity: 1\n",
+		"main.go":   "package main\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\tfor {\n\t}\n}\n",
+		"style.css": "body { color: red; }\n",
+	})
+
+	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, lang := range output.LanguageSummary {
+		if lang.Name == "CSS" {
+			t.Fatal("expected CSS to be excluded by config")
+		}
+	}
+	if len(output.Warnings) != 1 || !strings.Contains(output.Warnings[0], "main.go") {
+		t.Fatalf("expected a threshold warning for main.go, got %v", output.Warnings)
+	}
+
+	// Explicit input overrides the configured filter.
+	_, output, err = HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, ExcludeExtensions: []string{"go"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var languages []string
+	for _, lang := range output.LanguageSummary {
+		languages = append(languages, lang.Name)
+	}
+	if got := strings.Join(languages, ","); strings.Contains(got, "Go") || !strings.Contains(got, "CSS") {
+		t.Fatalf("expected input exclude_ext to override config, got %s", got)
+	}
+}
+
+func TestConfig_ConsultQuestions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".mtb.yaml": "consult:\n  questions:\n    - \"Has the platform team already built {problem}?\"\n",
+	})
+
+	_, output, err := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{Problem: "a job queue", Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Questions) != 8 {
+		t.Fatalf("expected 7 built-in + 1 extra question, got %d", len(output.Questions))
+	}
+	if output.Questions[7] != "Has the platform team already built a job queue?" {
+		t.Fatalf("unexpected extra question: %q", output.Questions[7])
+	}
+}
+
+func TestConfig_ConsultReplaceQuestions(t *testing.T) {
+	questions := configuredQuestions("x", ConsultConfig{Questions: []string{"only this"}, ReplaceQuestions: true})
+	if len(questions) != 1 || questions[0] != "only this" {
+		t.Fatalf("expected replacement questions, got %v", questions)
+	}
+}
+
+func TestConfig_ChecklistItems(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".mtb.yaml": "checklist:\n  items:\n    - category: Data retention\n      question: How long is customer data kept?\n      description: GDPR requires a documented retention policy.\n",
+	})
+
+	_, output, err := HandleChecklist(context.Background(), &mcp.CallToolRequest{}, ChecklistInput{Project: "billing", Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Items) != 7 {
+		t.Fatalf("expected 6 built-in + 1 configured item, got %d", len(output.Items))
+	}
+	last := output.Items[6]
+	if last.Category != "Data retention" || last.Status != "" {
+		t.Fatalf("expected configured item without detected status, got %+v", last)
+	}
+}
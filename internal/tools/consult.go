@@ -5,6 +5,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -16,23 +17,117 @@ type ConsultInput struct {
 }
 
 type ConsultOutput struct {
+	// SessionID identifies the saved session; answers are recorded with
+	// consult_answer.
+	SessionID string   `json:"sessionId,omitempty"`
 	Questions []string `json:"questions"`
-	Guidance  string   `json:"guidance"`
+	// Answers, Resolution, and Rationale are filled in when the client
+	// supports elicitation and the user answered in forms.
+	Answers      []SessionQuestion `json:"answers,omitempty"`
+	Resolution   string            `json:"resolution,omitempty"`
+	Rationale    string            `json:"rationale,omitempty"`
+	Alternatives []Alternative     `json:"alternatives,omitempty"`
+	Repositories []Repository      `json:"repositories,omitempty"`
+	Guidance     string            `json:"guidance"`
 }
 
+// maxRepositories is how many search results consult returns.
+const maxRepositories = 5
+
 func HandleConsult(ctx context.Context, req *mcp.CallToolRequest, input ConsultInput) (*mcp.CallToolResult, ConsultOutput, error) {
 	if input.Problem == "" {
 		return ErrResult[ConsultOutput]("problem is required")
 	}
 
-	questions := buildQuestions(input.Problem)
+	cfg, err := LoadConfig(input.Path)
+	if err != nil {
+		return ErrResult[ConsultOutput](err.Error())
+	}
+
+	questions := configuredQuestions(input.Problem, cfg.Consult)
+
+	var alternatives []Alternative
+	var domains []string
+	for _, d := range matchCatalog(input.Problem) {
+		alternatives = append(alternatives, d.Alternatives...)
+		domains = append(domains, d.Name)
+	}
+
+	provider, err := currentSearchProvider(cfg.Consult.Search)
+	if err != nil {
+		return ErrResult[ConsultOutput](err.Error())
+	}
+	var repos []Repository
+	var searchErr error
+	var searchedAt string
+	if query := searchQuery(input.Problem); query != "" {
+		repos, searchErr = provider.SearchRepositories(ctx, query, input.Language, maxRepositories)
+		if gh, ok := provider.(*GitHubSearch); ok {
+			searchedAt = gh.BaseURL
+		}
+	}
+
+	session, err := newConsultSession(input.Problem, questions, alternatives, repos)
+	if err != nil {
+		return ErrResult[ConsultOutput]("creating session: " + err.Error())
+	}
+	sessionErr := storeSession(ctx, input.Path, session)
+
+	// Clients that support elicitation show the questions to the user
+	// directly, so the agent cannot skip them or answer on the user's behalf.
+	var elicited elicitation
+	if canElicit(req) {
+		elicited = elicitConsult(ctx, req.Session, session, func() error {
+			if sessionErr != nil {
+				return nil
+			}
+			return storeSession(ctx, input.Path, session)
+		})
+	}
 
-	guidance := "IMPORTANT: Present each question above to the user and wait for their answers before proceeding. " +
-		"Do NOT skip questions or assume answers. The goal is to ensure the right problem is being solved " +
-		"with the right approach before any code is written. " +
-		"ALSO: Search the web for existing open-source projects, libraries, and SaaS products that already solve this problem. " +
-		"Present what you find to the user as alternatives before writing any code. " +
-		"Use your own knowledge of the problem domain to suggest well-known alternatives as well."
+	var guidance string
+	switch {
+	case elicited.completed():
+		guidance = "The user answered the consultation questions directly; their answers are in answers. Do NOT ask them again. " +
+			sessionGuidance(session, nil, session.Pending()) + " "
+	case elicited.Declined:
+		guidance = "The user declined to answer the consultation questions in a form. Do NOT answer them on the user's behalf; " +
+			"ask whether they would rather discuss the questions above in conversation before any code is written. "
+	default:
+		guidance = "IMPORTANT: Present each question above to the user and wait for their answers before proceeding. " +
+			"Do NOT skip questions or assume answers. The goal is to ensure the right problem is being solved " +
+			"with the right approach before any code is written. "
+		if elicited.Asked > 0 {
+			guidance += "The user already answered some of them directly; skip those (see answers). "
+		}
+	}
+	if sessionErr == nil && !elicited.completed() {
+		guidance += fmt.Sprintf("Record each answer with consult_answer (session_id %q, question_id is the question's number); "+
+			"it returns follow-up questions to ask and tracks the final decision to build, buy/adopt, or abandon. ", session.ID)
+	}
+	if elicited.Err != nil {
+		guidance += fmt.Sprintf("NOTE: Asking the user directly failed (%v). ", elicited.Err)
+	}
+	if len(alternatives) > 0 {
+		guidance += fmt.Sprintf("ALSO: This problem matches mtb's catalog of existing solutions (%s). "+
+			"Present the listed alternatives to the user, with license and deployment method, before writing any code, "+
+			"and ask why none of them would work. ", strings.Join(domains, "; "))
+	}
+	if len(repos) > 0 {
+		guidance += "ALSO: Present the matching repositories to the user with their stars, license, and last push date; " +
+			"a repository that has not been pushed to in a year or more may be unmaintained. "
+	}
+	if len(alternatives) == 0 && len(repos) == 0 {
+		guidance += "ALSO: Search the web, if available, for existing open-source projects, libraries, and SaaS products that already solve this problem. " +
+			"Present what you find to the user as alternatives before writing any code. "
+	}
+	if searchErr != nil {
+		guidance += fmt.Sprintf("NOTE: Repository search failed (%v). ", searchErr)
+	}
+	if searchedAt != "" {
+		guidance += fmt.Sprintf("NOTE: Key words from the problem description were sent to %s to search for repositories. ", searchedAt)
+	}
+	guidance += "Use your own knowledge of the problem domain to suggest well-known alternatives as well."
 
 	if input.Path != "" {
 		guidance += fmt.Sprintf(" Read the dependency manifest files in %q (e.g. go.mod, package.json, requirements.txt) "+
@@ -40,18 +135,49 @@ func HandleConsult(ctx context.Context, req *mcp.CallToolRequest, input ConsultI
 	}
 
 	output := ConsultOutput{
-		Questions: questions,
-		Guidance:  guidance,
+		Questions:    questions,
+		Alternatives: alternatives,
+		Repositories: repos,
+		Guidance:     guidance,
 	}
+	if sessionErr == nil {
+		output.SessionID = session.ID
+	}
+	for _, q := range session.Questions {
+		if q.AnsweredAt != nil {
+			output.Answers = append(output.Answers, q)
+		}
+	}
+	output.Resolution, output.Rationale = session.Resolution, session.Rationale
 
 	summary := fmt.Sprintf("Consultation for: %q\n", input.Problem)
+	if sessionErr == nil {
+		summary += fmt.Sprintf("Session: %s\n", session.ID)
+	}
 	summary += fmt.Sprintf("Generated %d questions to consider before proceeding.", len(questions))
+	if len(output.Answers) > 0 {
+		summary += fmt.Sprintf("\nThe user answered %d questions directly.", len(output.Answers))
+	}
+	if output.Resolution != "" {
+		summary += fmt.Sprintf("\nDecision: %s.", output.Resolution)
+	}
+	if len(alternatives) > 0 {
+		summary += fmt.Sprintf("\nFound %d existing alternatives in the catalog.", len(alternatives))
+	}
+	if len(repos) > 0 {
+		summary += fmt.Sprintf("\nFound %d matching repositories.", len(repos))
+	}
+	if searchedAt != "" {
+		summary += fmt.Sprintf("\nSearched %s.", searchedAt)
+	}
 
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
 	}, output, nil
 }
 
+// buildQuestions returns the built-in questions. builtinKinds tags them by
+// position, so the two must change together.
 func buildQuestions(problem string) []string {
 	return []string{
 		fmt.Sprintf("What is the actual problem you are trying to solve? (Restate the root cause behind %q)", problem),
@@ -63,3 +189,16 @@ func buildQuestions(problem string) []string {
 		"If you build this, who will maintain it when the requirements change?",
 	}
 }
+
+// configuredQuestions applies a project's consult configuration to the
+// built-in questions.
+func configuredQuestions(problem string, cfg ConsultConfig) []string {
+	var questions []string
+	if !cfg.ReplaceQuestions || len(cfg.Questions) == 0 {
+		questions = buildQuestions(problem)
+	}
+	for _, q := range cfg.Questions {
+		questions = append(questions, strings.ReplaceAll(q, "{problem}", problem))
+	}
+	return questions
+}
@@ -21,6 +21,9 @@ func TestHandleConsult_EmptyProblem(t *testing.T) {
 }
 
 func TestHandleConsult_ProblemOnly(t *testing.T) {
+	// consult looks for .mtb.yaml from the working directory.
+	t.Chdir(t.TempDir())
+
 	_, output, err := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{
 		Problem: "parse JSON",
 	})
@@ -65,3 +68,28 @@ func TestBuildQuestions(t *testing.T) {
 		t.Fatal("expected first question to reference the problem")
 	}
 }
+
+func TestHandleConsult_CatalogAlternatives(t *testing.T) {
+	// consult looks for .mtb.yaml from the working directory.
+	t.Chdir(t.TempDir())
+
+	_, output, err := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{
+		Problem: "We spend too much on Zendesk. Build me a simple support ticket system.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := false
+	for _, alt := range output.Alternatives {
+		if alt.Name == "Zammad" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected Zammad among alternatives, got %+v", output.Alternatives)
+	}
+	if strings.Contains(output.Guidance, "Search the web") {
+		t.Error("expected catalog guidance instead of web search")
+	}
+}
@@ -5,6 +5,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -14,7 +15,16 @@ type DepsInput struct {
 }
 
 type DepsOutput struct {
-	Guidance string `json:"guidance"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// Duplicates are capabilities more than one direct dependency provides.
+	Duplicates []DuplicateCapability `json:"duplicates,omitempty"`
+	// Lockfiles count the direct and transitive packages each lockfile
+	// resolves.
+	Lockfiles []LockfileSummary `json:"lockfiles,omitempty"`
+	// UnsupportedManifests lists manifests mtb recognized but cannot parse;
+	// the guidance covers those ecosystems.
+	UnsupportedManifests []string `json:"unsupportedManifests,omitempty"`
+	Guidance             string   `json:"guidance"`
 }
 
 func HandleDeps(ctx context.Context, req *mcp.CallToolRequest, input DepsInput) (*mcp.CallToolResult, DepsOutput, error) {
@@ -23,6 +33,57 @@ func HandleDeps(ctx context.Context, req *mcp.CallToolRequest, input DepsInput)
 		path = "."
 	}
 
+	deps, unsupported, err := scanManifests(path)
+	if err != nil {
+		return ErrResult[DepsOutput]("cannot scan project: " + err.Error())
+	}
+	for i, d := range deps {
+		if c, ok := lookupCapability(d.Ecosystem, d.Name); ok {
+			deps[i].Capability = c.ID
+		}
+	}
+	dups := findDuplicates(deps)
+	locks := scanLockfiles(path, deps)
+
+	if len(deps) > 0 && len(unsupported) == 0 {
+		direct := 0
+		for _, d := range deps {
+			if d.Direct {
+				direct++
+			}
+		}
+		guidance := fmt.Sprintf("IMPORTANT: Present the %d dependencies parsed from the manifests in %q to the user, "+
+			"organized by ecosystem with name, version, and whether they are direct or indirect. "+
+			"Flag outdated versions, duplicate functionality, or dependencies that could be consolidated. "+
+			"Check whether an existing dependency already covers the need before suggesting a new one. "+
+			"Every unnecessary dependency increases maintenance cost, security exposure, and build times.", len(deps), path)
+		if len(dups) > 0 {
+			guidance += duplicatesGuidance(dups)
+		}
+		if len(locks) > 0 {
+			guidance += lockfilesGuidance(locks)
+		}
+
+		output := DepsOutput{
+			Dependencies: deps,
+			Duplicates:   dups,
+			Lockfiles:    locks,
+			Guidance:     guidance,
+		}
+
+		summary := fmt.Sprintf("Found %d dependencies (%d direct) in %q.", len(deps), direct, path)
+		for _, d := range dups {
+			summary += fmt.Sprintf("\nDuplicate %s (%s): %s", d.Name, d.Ecosystem, strings.Join(d.Packages, ", "))
+		}
+		for _, l := range locks {
+			summary += fmt.Sprintf("\n%s: %d direct, %d transitive", l.Lockfile, l.Direct, l.Transitive)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+		}, output, nil
+	}
+
 	guidance := fmt.Sprintf(`IMPORTANT: You must identify the existing dependencies in %q before suggesting any new ones. Follow these steps:
 
 1. **Identify the ecosystem.** Look for dependency manifest files such as:
@@ -53,8 +114,23 @@ For a deeper analysis (transitive dependencies, vulnerability scanning), suggest
 
 Every unnecessary dependency increases maintenance cost, security exposure, and build times. Present findings before suggesting additions.`, path)
 
+	if len(unsupported) > 0 {
+		guidance += fmt.Sprintf("\n\nmtb parsed %d dependencies itself but cannot read these manifests; read them yourself: %s",
+			len(deps), strings.Join(unsupported, ", "))
+	}
+	if len(dups) > 0 {
+		guidance += duplicatesGuidance(dups)
+	}
+	if len(locks) > 0 {
+		guidance += lockfilesGuidance(locks)
+	}
+
 	output := DepsOutput{
-		Guidance: guidance,
+		Dependencies:         deps,
+		Duplicates:           dups,
+		Lockfiles:            locks,
+		UnsupportedManifests: unsupported,
+		Guidance:             guidance,
 	}
 
 	summary := fmt.Sprintf("Dependency scan guidance for: %q\nRead manifest files and present existing dependencies before suggesting new ones.", path)
@@ -4,6 +4,7 @@ package tools
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -20,7 +21,7 @@ func TestHandleDeps_DefaultPath(t *testing.T) {
 }
 
 func TestHandleDeps_WithPath(t *testing.T) {
-	_, output, err := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: "/some/project"})
+	_, output, err := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: t.TempDir()})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -28,3 +29,75 @@ func TestHandleDeps_WithPath(t *testing.T) {
 		t.Fatal("expected guidance to be non-empty")
 	}
 }
+
+func TestHandleDeps_MissingPath(t *testing.T) {
+	result, _, _ := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: "/some/project"})
+	if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "cannot scan project") {
+		t.Fatalf("expected an error for a missing path, got %+v", result)
+	}
+}
+
+func TestHandleDeps_ParsesManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                          "module example.com/app\n\nrequire github.com/a/b v1.0.0\n",
+		"web/package.json":                `{"dependencies": {"axios": "^1.6.0"}}`,
+		"web/node_modules/x/package.json": `{"dependencies": {"ignored": "1"}}`,
+	})
+
+	_, output, err := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(output.Dependencies) != 2 {
+		t.Fatalf("expected 2 dependencies, got %+v", output.Dependencies)
+	}
+	if output.Dependencies[0].Manifest != "go.mod" || output.Dependencies[1].Manifest != "web/package.json" {
+		t.Errorf("unexpected manifests: %+v", output.Dependencies)
+	}
+	if len(output.UnsupportedManifests) != 0 {
+		t.Errorf("expected no unsupported manifests, got %v", output.UnsupportedManifests)
+	}
+}
+
+func TestHandleDeps_UnsupportedFallsBackToGuidance(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":  "module example.com/app\n\nrequire github.com/a/b v1.0.0\n",
+		"Gemfile": "gem 'rails'\n",
+	})
+
+	_, output, err := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(output.UnsupportedManifests) != 1 || output.UnsupportedManifests[0] != "Gemfile" {
+		t.Fatalf("expected Gemfile to be reported as unsupported, got %v", output.UnsupportedManifests)
+	}
+	if !strings.Contains(output.Guidance, "Gemfile") || !strings.Contains(output.Guidance, "Ruby") {
+		t.Errorf("expected fallback guidance mentioning Gemfile, got %q", output.Guidance)
+	}
+}
+
+func TestHandleDeps_Duplicates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\nrequire (\n\tgithub.com/sirupsen/logrus v1.9.3\n\tgo.uber.org/zap v1.27.0\n)\n",
+	})
+
+	_, output, err := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Duplicates) != 1 || output.Duplicates[0].Capability != "logging" {
+		t.Fatalf("expected duplicate logging libraries, got %+v", output.Duplicates)
+	}
+	if output.Dependencies[0].Capability != "logging" {
+		t.Errorf("expected dependencies to be tagged with their capability, got %+v", output.Dependencies[0])
+	}
+	if !strings.Contains(output.Guidance, "Logging (go): github.com/sirupsen/logrus, go.uber.org/zap") {
+		t.Errorf("expected the duplicate in the guidance, got %q", output.Guidance)
+	}
+}
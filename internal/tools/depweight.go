@@ -0,0 +1,502 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type DepWeightInput struct {
+	Path            string `json:"path,omitempty" jsonschema:"project directory whose dependencies to weigh (default .)"`
+	IncludeIndirect bool   `json:"include_indirect,omitempty" jsonschema:"also weigh indirect dependencies listed in the manifests, e.g. go.mod // indirect requires"`
+	TopN            int    `json:"top_n,omitempty" jsonschema:"number of dependencies to return, heaviest first (default 20)"`
+}
+
+// DependencyWeight is the size of one dependency's source as scc counts it.
+type DependencyWeight struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	// Version is the installed version when the source records it, else
+	// the one the manifest asks for.
+	Version string `json:"version,omitempty"`
+	Direct  bool   `json:"direct"`
+	// Source is the directory that was analyzed.
+	Source        string  `json:"source"`
+	Files         int64   `json:"files"`
+	Code          int64   `json:"code"`
+	Complexity    int64   `json:"complexity"`
+	EstimatedCost float64 `json:"estimatedCost"`
+}
+
+// MissingDependency is a dependency whose source is not on disk.
+type MissingDependency struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+type DepWeightOutput struct {
+	Dependencies []DependencyWeight  `json:"dependencies"`
+	Missing      []MissingDependency `json:"missing,omitempty"`
+	// Code, Complexity, and EstimatedCost cover every dependency found,
+	// including those beyond top_n, estimated as one codebase.
+	Code          int64       `json:"code"`
+	Complexity    int64       `json:"complexity"`
+	EstimatedCost float64     `json:"estimatedCost"`
+	Cocomo        CocomoModel `json:"cocomo"`
+	Table         string      `json:"table"`
+	Guidance      string      `json:"guidance"`
+}
+
+// defaultDepWeightTopN is the number of dependencies returned unless the
+// input says otherwise.
+const defaultDepWeightTopN = 20
+
+func HandleDepWeight(ctx context.Context, req *mcp.CallToolRequest, input DepWeightInput) (*mcp.CallToolResult, DepWeightOutput, error) {
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[DepWeightOutput]("invalid path: " + err.Error())
+	}
+	if input.TopN < 0 {
+		return ErrResult[DepWeightOutput]("top_n must not be negative")
+	}
+	topN := input.TopN
+	if topN == 0 {
+		topN = defaultDepWeightTopN
+	}
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[DepWeightOutput](err.Error())
+	}
+	model, err := resolveCocomo(CocomoModel{}, cfg.Cocomo)
+	if err != nil {
+		return ErrResult[DepWeightOutput](err.Error())
+	}
+
+	deps, _, err := scanManifests(absPath)
+	if err != nil {
+		return ErrResult[DepWeightOutput]("cannot scan project: " + err.Error())
+	}
+
+	// The same dependency in several manifests of a monorepo usually
+	// resolves to the same source; weigh it once.
+	var weights []DependencyWeight
+	var sources []depSource
+	var missing []MissingDependency
+	seen := map[string]bool{}
+	for _, d := range deps {
+		if !d.Direct && !input.IncludeIndirect {
+			continue
+		}
+		src, err := locateSource(absPath, d)
+		if err != nil {
+			missing = append(missing, MissingDependency{Ecosystem: d.Ecosystem, Name: d.Name, Reason: err.Error()})
+			continue
+		}
+		if key := d.Ecosystem + "\x00" + strings.Join(src.paths, "\x00"); !seen[key] {
+			seen[key] = true
+			version := src.version
+			if version == "" {
+				version = d.Version
+			}
+			weights = append(weights, DependencyWeight{
+				Ecosystem: d.Ecosystem,
+				Name:      d.Name,
+				Version:   version,
+				Direct:    d.Direct,
+				Source:    src.paths[0],
+			})
+			sources = append(sources, src)
+		}
+	}
+
+	if err := weighSources(ctx, weights, sources, model); err != nil {
+		return ErrResult[DepWeightOutput]("analysis failed: " + err.Error())
+	}
+	sort.SliceStable(weights, func(i, j int) bool {
+		a, b := weights[i], weights[j]
+		if a.Code != b.Code {
+			return a.Code > b.Code
+		}
+		if a.Complexity != b.Complexity {
+			return a.Complexity > b.Complexity
+		}
+		return a.Name < b.Name
+	})
+
+	output := DepWeightOutput{Missing: missing, Cocomo: model}
+	for _, w := range weights {
+		output.Code += w.Code
+		output.Complexity += w.Complexity
+	}
+	output.EstimatedCost = model.cost(output.Code)
+	if topN < len(weights) {
+		weights = weights[:topN]
+	}
+	output.Dependencies = weights
+	output.Table = renderDepWeightTable(weights, model.Currency)
+
+	switch {
+	case len(output.Dependencies) == 0 && len(missing) == 0:
+		output.Guidance = fmt.Sprintf("No dependencies were found in the manifests under %s. Tell the user there is nothing to weigh.", absPath)
+	case len(output.Dependencies) == 0:
+		output.Guidance = "The source of none of the dependencies is on disk. " + missingGuidance
+	default:
+		heaviest := output.Dependencies[0]
+		output.Guidance = fmt.Sprintf("IMPORTANT: Present the dependencies by weight to the user. Every line a dependency brings in "+
+			"costs maintenance and security exposure just like a line the project writes: together they are %d lines of code "+
+			"with complexity %d, an estimated %s to build. The heaviest is %s with %d lines. "+
+			"Point out dependencies that bring in far more code than the project uses them for, and discuss replacing them "+
+			"with a smaller library, the standard library, or a few lines of the project's own. "+
+			"Do NOT remove or replace a dependency without the user's approval.",
+			output.Code, output.Complexity, FormatMoney(output.EstimatedCost, model.Currency), heaviest.Name, heaviest.Code)
+		if len(missing) > 0 {
+			output.Guidance += fmt.Sprintf(" %d dependencies were not weighed because their source is not on disk. ", len(missing)) + missingGuidance
+		}
+	}
+
+	summary := fmt.Sprintf("Dependency weight for %s: %d lines of code, complexity %d, %s (%s).\n\n%s",
+		absPath, output.Code, output.Complexity, FormatMoney(output.EstimatedCost, model.Currency), model, output.Table)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+	}, output, nil
+}
+
+// missingGuidance tells the agent how to get dependency sources on disk.
+const missingGuidance = "Offer to fetch them first (go mod download, npm install, pip install into .venv, or cargo fetch) and run dep_weight again."
+
+// depSource is where a dependency's code lives: usually one directory, but
+// a Python distribution may install several top-level packages and modules.
+type depSource struct {
+	paths   []string
+	version string
+}
+
+// weighSources runs scc on each source in parallel and fills in the
+// matching weights.
+func weighSources(ctx context.Context, weights []DependencyWeight, sources []depSource, model CocomoModel) error {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		first error
+	)
+	for i, src := range sources {
+		for _, p := range src.paths {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Nested node_modules hold other packages' code.
+				stats, err := RunSCC(ctx, p, nil, true, []string{"node_modules"}, nil, nil)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					if first == nil {
+						first = fmt.Errorf("%s: %w", weights[i].Name, err)
+					}
+					return
+				}
+				for _, l := range stats.LanguageSummary {
+					weights[i].Files += l.Count
+					weights[i].Code += l.Code
+					weights[i].Complexity += l.Complexity
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	for i := range weights {
+		weights[i].EstimatedCost = model.cost(weights[i].Code)
+	}
+	return first
+}
+
+// locateSource finds a dependency's source on disk, starting from the
+// directory of the manifest that declares it.
+func locateSource(root string, d Dependency) (depSource, error) {
+	dir := filepath.Join(root, filepath.FromSlash(filepath.Dir(d.Manifest)))
+	switch d.Ecosystem {
+	case EcosystemGo:
+		return locateGoModule(dir, d)
+	case EcosystemNPM:
+		return locateNodeModule(root, dir, d.Name)
+	case EcosystemPyPI:
+		return locatePythonDistribution(root, dir, d.Name)
+	case EcosystemCargo:
+		return locateCrate(root, dir, d.Name)
+	}
+	return depSource{}, fmt.Errorf("unsupported ecosystem %q", d.Ecosystem)
+}
+
+// locateGoModule looks in the module's replacement, vendor/, and the module
+// cache, in that order.
+func locateGoModule(dir string, d Dependency) (depSource, error) {
+	mod, version := d.Name, d.Version
+	if d.Replace != "" {
+		target := strings.Fields(d.Replace)
+		if strings.HasPrefix(target[0], ".") || filepath.IsAbs(target[0]) {
+			p := target[0]
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(dir, p)
+			}
+			return existingSource(p, "", "replacement "+d.Replace)
+		}
+		mod = target[0]
+		if len(target) > 1 {
+			version = target[1]
+		}
+	}
+	// go mod vendor copies a replaced module under its original path.
+	if _, err := os.Stat(filepath.Join(dir, "vendor", "modules.txt")); err == nil {
+		return existingSource(filepath.Join(dir, "vendor", filepath.FromSlash(d.Name)), version, "vendor/"+d.Name)
+	}
+	cache := goModCache()
+	if cache == "" {
+		return depSource{}, fmt.Errorf("cannot find the Go module cache")
+	}
+	return existingSource(filepath.Join(cache, escapeModulePath(mod)+"@"+escapeModulePath(version)), version, mod+"@"+version+" in the module cache")
+}
+
+// goModCache returns the module cache directory the way the go command
+// chooses it, without running it.
+func goModCache() string {
+	if c := os.Getenv("GOMODCACHE"); c != "" {
+		return c
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// escapeModulePath applies the module cache's case encoding: each upper
+// case letter becomes '!' followed by its lower case form.
+func escapeModulePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// locateNodeModule resolves a package the way Node does, from the
+// manifest's node_modules up to the scan root's.
+func locateNodeModule(root, dir, name string) (depSource, error) {
+	for {
+		p := filepath.Join(dir, "node_modules", filepath.FromSlash(name))
+		if _, err := os.Stat(p); err == nil {
+			var pkg struct {
+				Version string `json:"version"`
+			}
+			if data, err := os.ReadFile(filepath.Join(p, "package.json")); err == nil {
+				json.Unmarshal(data, &pkg)
+			}
+			return depSource{paths: []string{p}, version: pkg.Version}, nil
+		}
+		if rel, err := filepath.Rel(root, dir); err != nil || rel == "." {
+			return depSource{}, fmt.Errorf("not installed in node_modules")
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// pythonEnvs are the virtual environment directories searched for
+// site-packages, relative to the manifest and the scan root.
+var pythonEnvs = []string{".venv", "venv"}
+
+// locatePythonDistribution finds an installed distribution in a virtual
+// environment's site-packages and returns the top-level packages and
+// modules its RECORD lists.
+func locatePythonDistribution(root, dir, name string) (depSource, error) {
+	var sites []string
+	for _, base := range []string{dir, root} {
+		for _, env := range pythonEnvs {
+			for _, pattern := range []string{"lib/python*/site-packages", "Lib/site-packages"} {
+				matches, _ := filepath.Glob(filepath.Join(base, env, filepath.FromSlash(pattern)))
+				sites = append(sites, matches...)
+			}
+		}
+	}
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		matches, _ := filepath.Glob(filepath.Join(venv, "lib", "python*", "site-packages"))
+		sites = append(sites, matches...)
+	}
+	if len(sites) == 0 {
+		return depSource{}, fmt.Errorf("no virtual environment (.venv or venv) with site-packages")
+	}
+
+	want := normalizePackage(EcosystemPyPI, name)
+	for _, site := range sites {
+		infos, _ := filepath.Glob(filepath.Join(site, "*.dist-info"))
+		for _, info := range infos {
+			dist, version, _ := strings.Cut(strings.TrimSuffix(filepath.Base(info), ".dist-info"), "-")
+			if normalizePackage(EcosystemPyPI, dist) != want {
+				continue
+			}
+			paths := distributionTopLevel(site, info)
+			if len(paths) == 0 {
+				return depSource{}, fmt.Errorf("%s lists no installed files", filepath.Base(info))
+			}
+			return depSource{paths: paths, version: version}, nil
+		}
+	}
+	return depSource{}, fmt.Errorf("not installed in %s", strings.Join(sites, ", "))
+}
+
+// distributionTopLevel reads a dist-info RECORD and returns the top-level
+// entries it installed into site, leaving out metadata, caches, and
+// scripts installed elsewhere.
+func distributionTopLevel(site, info string) []string {
+	data, err := os.ReadFile(filepath.Join(info, "RECORD"))
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		file, _, _ := strings.Cut(line, ",")
+		top, _, _ := strings.Cut(strings.Trim(file, `"`), "/")
+		if top == "" || top == ".." || top == "__pycache__" || strings.HasSuffix(top, ".dist-info") || seen[top] {
+			continue
+		}
+		seen[top] = true
+		if _, err := os.Stat(filepath.Join(site, top)); err == nil {
+			paths = append(paths, filepath.Join(site, top))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// locateCrate looks in vendor/ and then cargo's registry sources, picking
+// the version Cargo.lock pins or else the newest one unpacked.
+func locateCrate(root, dir, name string) (depSource, error) {
+	locked := cargoLockedVersions(root, dir)[name]
+	for _, base := range []string{dir, root} {
+		for _, v := range append(locked, "") {
+			p := filepath.Join(base, "vendor", name)
+			if v != "" {
+				p += "-" + v
+			}
+			if _, err := os.Stat(filepath.Join(p, "Cargo.toml")); err == nil {
+				return depSource{paths: []string{p}, version: v}, nil
+			}
+		}
+	}
+
+	cargoHome := os.Getenv("CARGO_HOME")
+	if cargoHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return depSource{}, fmt.Errorf("cannot find cargo's home directory")
+		}
+		cargoHome = filepath.Join(home, ".cargo")
+	}
+	matches, _ := filepath.Glob(filepath.Join(cargoHome, "registry", "src", "*", name+"-*"))
+	var best, bestVersion string
+	for _, m := range matches {
+		v := strings.TrimPrefix(filepath.Base(m), name+"-")
+		// Another crate may share the prefix, e.g. serde and serde-json.
+		if v == "" || v[0] < '0' || v[0] > '9' {
+			continue
+		}
+		for _, l := range locked {
+			if v == l {
+				return depSource{paths: []string{m}, version: v}, nil
+			}
+		}
+		if len(locked) == 0 && (best == "" || compareVersions(v, bestVersion) > 0) {
+			best, bestVersion = m, v
+		}
+	}
+	if best == "" {
+		return depSource{}, fmt.Errorf("not in vendor/ or cargo's registry sources")
+	}
+	return depSource{paths: []string{best}, version: bestVersion}, nil
+}
+
+// cargoLockedVersions reads the versions each crate is locked at from the
+// nearest Cargo.lock between dir and root.
+func cargoLockedVersions(root, dir string) map[string][]string {
+	versions := map[string][]string{}
+	for {
+		if data, err := os.ReadFile(filepath.Join(dir, "Cargo.lock")); err == nil {
+			names := map[int]string{}
+			for _, e := range parseTOML(string(data)) {
+				if e.Table == "package" && e.Key == "name" {
+					names[e.Index] = tomlString(e.Value)
+				}
+			}
+			for _, e := range parseTOML(string(data)) {
+				if e.Table == "package" && e.Key == "version" {
+					versions[names[e.Index]] = append(versions[names[e.Index]], tomlString(e.Value))
+				}
+			}
+			return versions
+		}
+		if rel, err := filepath.Rel(root, dir); err != nil || rel == "." {
+			return versions
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// compareVersions compares dotted versions numerically, part by part,
+// falling back to string comparison for parts that are not numbers.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			return x - y
+		case (errX != nil || errY != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// existingSource returns p as the source if it exists.
+func existingSource(p, version, what string) (depSource, error) {
+	if _, err := os.Stat(p); err != nil {
+		return depSource{}, fmt.Errorf("%s is not on disk", what)
+	}
+	return depSource{paths: []string{p}, version: version}, nil
+}
+
+// renderDepWeightTable renders a markdown table of dependencies by weight.
+func renderDepWeightTable(weights []DependencyWeight, currency string) string {
+	var b strings.Builder
+	b.WriteString("| # | Dependency | Version | Files | Code | Complexity | Est. cost |\n")
+	b.WriteString("|---|------------|---------|-------|------|------------|-----------|\n")
+	for i, w := range weights {
+		fmt.Fprintf(&b, "| %d | %s (%s) | %s | %d | %d | %d | %s |\n",
+			i+1, w.Name, w.Ecosystem, w.Version, w.Files, w.Code, w.Complexity, FormatMoney(w.EstimatedCost, currency))
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestHandleDepWeight(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	cargo := t.TempDir()
+	t.Setenv("GOMODCACHE", cache)
+	t.Setenv("CARGO_HOME", cargo)
+	t.Setenv("VIRTUAL_ENV", "")
+
+	writeFiles(t, cache, map[string]string{
+		"example.com/!big@v1.2.0/big.go":    complexGo,
+		"example.com/!big@v1.2.0/util.go":   "package big\n\nfunc Util() int {\n\treturn 1\n}\n",
+		"example.com/small@v0.1.0/small.go": "package small\n\nfunc Small() {}\n",
+	})
+	writeFiles(t, cargo, map[string]string{
+		"registry/src/index.crates.io-6f17d22bba15001f/tiny-1.0.0/Cargo.toml":       "[package]\nname = \"tiny\"\n",
+		"registry/src/index.crates.io-6f17d22bba15001f/tiny-1.2.0/Cargo.toml":       "[package]\nname = \"tiny\"\n",
+		"registry/src/index.crates.io-6f17d22bba15001f/tiny-1.2.0/src/lib.rs":       "pub fn tiny() -> u8 {\n    if true { 1 } else { 2 }\n}\n",
+		"registry/src/index.crates.io-6f17d22bba15001f/tiny-extra-9.0.0/Cargo.toml": "[package]\nname = \"tiny-extra\"\n",
+	})
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                                        "module example.com/app\n\nrequire (\n\texample.com/Big v1.2.0\n\texample.com/small v0.1.0 // indirect\n\texample.com/gone v1.0.0\n)\n",
+		"web/package.json":                              `{"dependencies": {"left-pad": "^1.0.0"}}`,
+		"web/node_modules/left-pad/package.json":        `{"name": "left-pad", "version": "1.3.0"}`,
+		"web/node_modules/left-pad/index.js":            "module.exports = function (s) {\n  return s;\n};\n",
+		"web/node_modules/left-pad/node_modules/x/a.js": "var a = 1;\nvar b = 2;\nvar c = 3;\nvar d = 4;\nvar e = 5;\n",
+		"py/requirements.txt":                           "Six==1.16.0\n",
+		"py/.venv/.gitignore":                           "*\n",
+		"py/.venv/lib/python3.12/site-packages/six-1.16.0.dist-info/RECORD": "six.py,sha256=x,100\n" +
+			"six-1.16.0.dist-info/RECORD,,\n__pycache__/six.cpython-312.pyc,,\n../../../bin/six,,\n",
+		"py/.venv/lib/python3.12/site-packages/six.py": "import sys\n\nPY3 = sys.version_info[0] == 3\n",
+		"rs/Cargo.toml": "[package]\nname = \"app\"\n\n[dependencies]\ntiny = \"1\"\n",
+	})
+
+	_, out, err := HandleDepWeight(context.Background(), &mcp.CallToolRequest{}, DepWeightInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]DependencyWeight{}
+	for _, w := range out.Dependencies {
+		got[w.Name] = w
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected four weighed dependencies, got %+v (missing %+v)", out.Dependencies, out.Missing)
+	}
+	if out.Dependencies[0].Name != "example.com/Big" || got["example.com/Big"].Files != 2 || got["example.com/Big"].Complexity == 0 {
+		t.Errorf("expected example.com/Big to be the heaviest, got %+v", out.Dependencies)
+	}
+	if w := got["left-pad"]; w.Version != "1.3.0" || w.Files != 2 || w.Code != 4 {
+		t.Errorf("expected left-pad 1.3.0 without its nested node_modules, got %+v", w)
+	}
+	if w := got["Six"]; w.Version != "1.16.0" || w.Code != 2 {
+		t.Errorf("expected six.py to be weighed, got %+v", w)
+	}
+	if w := got["tiny"]; w.Version != "1.2.0" || filepath.Base(w.Source) != "tiny-1.2.0" {
+		t.Errorf("expected the newest tiny crate, got %+v", w)
+	}
+	if _, ok := got["example.com/small"]; ok {
+		t.Error("indirect dependencies should be left out by default")
+	}
+	if len(out.Missing) != 1 || out.Missing[0].Name != "example.com/gone" {
+		t.Errorf("expected example.com/gone to be missing, got %+v", out.Missing)
+	}
+	if out.EstimatedCost == 0 || out.Cocomo.ProjectType != "organic" || !strings.Contains(out.Table, "| 1 | example.com/Big (go) | v1.2.0 |") {
+		t.Errorf("unexpected output: %+v", out)
+	}
+
+	_, out, err = HandleDepWeight(context.Background(), &mcp.CallToolRequest{}, DepWeightInput{Path: dir, IncludeIndirect: true, TopN: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Dependencies) != 2 {
+		t.Errorf("expected top_n to limit the result, got %+v", out.Dependencies)
+	}
+}
+
+func TestLocateCrate_Locked(t *testing.T) {
+	dir := t.TempDir()
+	cargo := t.TempDir()
+	t.Setenv("CARGO_HOME", cargo)
+	writeFiles(t, cargo, map[string]string{
+		"registry/src/a/tiny-1.0.0/Cargo.toml": "",
+		"registry/src/a/tiny-1.2.0/Cargo.toml": "",
+	})
+	writeFiles(t, dir, map[string]string{
+		"Cargo.lock":              "[[package]]\nname = \"tiny\"\nversion = \"1.0.0\"\nsource = \"registry+https://github.com/rust-lang/crates.io-index\"\n",
+		"vendor/other/Cargo.toml": "",
+	})
+
+	src, err := locateCrate(dir, filepath.Join(dir, "member"), "tiny")
+	if err != nil || src.version != "1.0.0" {
+		t.Errorf("locateCrate() = %+v, %v; want the locked 1.0.0", src, err)
+	}
+}
+
+func TestLocateGoModule_VendoredReplacement(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"vendor/modules.txt":              "# example.com/orig v1.0.0 => example.com/fork v1.1.0\n",
+		"vendor/example.com/orig/orig.go": "package orig\n",
+	})
+
+	src, err := locateGoModule(dir, Dependency{Name: "example.com/orig", Version: "v1.0.0", Replace: "example.com/fork v1.1.0"})
+	if err != nil || src.paths[0] != filepath.Join(dir, "vendor", "example.com", "orig") || src.version != "v1.1.0" {
+		t.Errorf("locateGoModule() = %+v, %v; want the vendored copy under the original path", src, err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0", "1.0.0", 0},
+		{"0.9", "0.9.1", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); (got > 0) != (c.want > 0) || (got < 0) != (c.want < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign of %d", c.a, c.b, got, c.want)
+		}
+	}
+}
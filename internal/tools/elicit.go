@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Checklist answers offered in elicitation forms.
+var checklistAnswers = []string{"addressed", "partially addressed", "not addressed", "not needed"}
+
+// elicitation is the outcome of asking the user questions directly.
+type elicitation struct {
+	// Asked is the number of forms the user answered.
+	Asked int
+	// Declined is set when the user declined or dismissed a form.
+	Declined bool
+	// Err is set when a form could not be shown or its answer saved.
+	Err error
+}
+
+// completed reports whether every form was shown and answered.
+func (e elicitation) completed() bool {
+	return e.Asked > 0 && !e.Declined && e.Err == nil
+}
+
+// stop records why elicitation ended early.
+func (e *elicitation) stop(err error) {
+	if errors.Is(err, errDeclined) {
+		e.Declined = true
+	} else {
+		e.Err = err
+	}
+}
+
+// errDeclined stops elicitation when the user declines a form.
+var errDeclined = errors.New("declined")
+
+// canElicit reports whether the client calling a tool advertised support
+// for form elicitation. Tools fall back to guidance text when it did not.
+func canElicit(req *mcp.CallToolRequest) bool {
+	if req == nil || req.Session == nil {
+		return false
+	}
+	params := req.Session.InitializeParams()
+	if params == nil || params.Capabilities == nil || params.Capabilities.Elicitation == nil {
+		return false
+	}
+	// A client that advertises neither mode predates form and URL modes and
+	// supports forms.
+	caps := params.Capabilities.Elicitation
+	return caps.Form != nil || caps.URL == nil
+}
+
+// elicitForm shows message with a form of the given properties and returns
+// what the user submitted. No property is marked required: the SDK checks
+// declined responses, which carry no content, against the schema too.
+func elicitForm(ctx context.Context, ss *mcp.ServerSession, message string, properties map[string]any) (map[string]any, error) {
+	res, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		Message: message,
+		RequestedSchema: map[string]any{
+			"type":       "object",
+			"properties": properties,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Action != "accept" {
+		return nil, errDeclined
+	}
+	return res.Content, nil
+}
+
+// elicitConsult asks the session's pending questions one at a time,
+// following each answer with the questions it prompts, and then asks for a
+// decision. save is called after every answer so a dropped connection
+// loses nothing.
+func elicitConsult(ctx context.Context, ss *mcp.ServerSession, s *ConsultSession, save func() error) elicitation {
+	var e elicitation
+
+	queue := s.Pending()
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+		content, err := elicitForm(ctx, ss, fmt.Sprintf("Before any code is written for %q:\n\n%s", s.Problem, q.Question), map[string]any{
+			"answer": map[string]any{"type": "string", "title": "Your answer", "minLength": 1},
+		})
+		if err != nil {
+			e.stop(err)
+			return e
+		}
+		answer, _ := content["answer"].(string)
+		if strings.TrimSpace(answer) == "" {
+			e.stop(errDeclined)
+			return e
+		}
+		added, err := s.answer(q.ID, answer)
+		if err != nil {
+			e.stop(err)
+			return e
+		}
+		if err := save(); err != nil {
+			e.stop(fmt.Errorf("saving session: %w", err))
+			return e
+		}
+		e.Asked++
+		queue = append(added, queue...)
+	}
+
+	if s.Resolution != "" {
+		return e
+	}
+	content, err := elicitForm(ctx, ss, fmt.Sprintf("Having answered these questions, what should happen with %q?", s.Problem), map[string]any{
+		"resolution": map[string]any{
+			"type":      "string",
+			"title":     "Decision",
+			"enum":      []string{ResolutionBuild, ResolutionAdopt, ResolutionAbandon},
+			"enumNames": []string{"Build it", "Buy or adopt an existing solution", "Abandon it"},
+		},
+		"rationale": map[string]any{"type": "string", "title": "Why?"},
+	})
+	if err != nil {
+		e.stop(err)
+		return e
+	}
+	resolution, _ := content["resolution"].(string)
+	if s.Resolution, _ = parseResolution(resolution); s.Resolution != "" {
+		s.Rationale, _ = content["rationale"].(string)
+	}
+	if err := save(); err != nil {
+		e.stop(fmt.Errorf("saving session: %w", err))
+		return e
+	}
+	e.Asked++
+	return e
+}
+
+// elicitChecklist asks the user whether each item is addressed, recording
+// the answers on the items.
+func elicitChecklist(ctx context.Context, ss *mcp.ServerSession, project string, items []ChecklistItem) elicitation {
+	var e elicitation
+	for i := range items {
+		item := &items[i]
+		var msg strings.Builder
+		fmt.Fprintf(&msg, "%s for %q\n\n%s\n\n%s", item.Category, project, item.Question, item.Description)
+		if item.Status != "" {
+			fmt.Fprintf(&msg, "\n\nmtb's scan: %s", item.Status)
+			if len(item.Evidence) > 0 {
+				fmt.Fprintf(&msg, " (%s)", strings.Join(item.Evidence, ", "))
+			}
+		}
+		content, err := elicitForm(ctx, ss, msg.String(), map[string]any{
+			"answer": map[string]any{"type": "string", "title": "Is this addressed?", "enum": checklistAnswers},
+			"notes":  map[string]any{"type": "string", "title": "Notes or next steps"},
+		})
+		if err != nil {
+			e.stop(err)
+			return e
+		}
+		item.Answer, _ = content["answer"].(string)
+		item.Notes, _ = content["notes"].(string)
+		e.Asked++
+	}
+	return e
+}
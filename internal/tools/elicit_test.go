@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connect serves consult and checklist to an in-memory client. A nil
+// elicit connects a client without the elicitation capability.
+func connect(t *testing.T, elicit func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error)) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "mtb", Version: "test"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "consult"}, HandleConsult)
+	mcp.AddTool(server, &mcp.Tool{Name: "checklist"}, HandleChecklist)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, &mcp.ClientOptions{ElicitationHandler: elicit})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func callConsult(t *testing.T, session *mcp.ClientSession, dir string) ConsultOutput {
+	t.Helper()
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "consult",
+		Arguments: ConsultInput{Problem: "a helpdesk", Path: dir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error result: %v", res.Content)
+	}
+	var output ConsultOutput
+	data, _ := json.Marshal(res.StructuredContent)
+	if err := json.Unmarshal(data, &output); err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestConsult_Elicitation(t *testing.T) {
+	dir := t.TempDir()
+	var messages []string
+	session := connect(t, func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		messages = append(messages, req.Params.Message)
+		props := req.Params.RequestedSchema.(map[string]any)["properties"].(map[string]any)
+		if _, ok := props["resolution"]; ok {
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"resolution": "buy/adopt", "rationale": "Zammad does it."}}, nil
+		}
+		return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"answer": "Because tickets get lost."}}, nil
+	})
+
+	output := callConsult(t, session, dir)
+
+	// Seven questions, four more "why"s down the root-cause chain, a
+	// follow-up to the alternatives answer, and the decision.
+	if len(messages) != 13 || len(output.Answers) != 12 {
+		t.Fatalf("expected 13 forms and 12 answers, got %d and %d", len(messages), len(output.Answers))
+	}
+	if !strings.Contains(messages[1], "Why?") {
+		t.Errorf("expected the why chain to follow the root-cause question, got %q", messages[1])
+	}
+	if output.Resolution != ResolutionAdopt || output.Rationale != "Zammad does it." {
+		t.Errorf("unexpected decision: %q %q", output.Resolution, output.Rationale)
+	}
+	if !strings.Contains(output.Guidance, "Do NOT ask them again") || strings.Contains(output.Guidance, "Present each question") {
+		t.Errorf("unexpected guidance: %q", output.Guidance)
+	}
+
+	saved := answer(t, ConsultAnswerInput{SessionID: output.SessionID, Path: dir})
+	if saved.Session.Resolution != ResolutionAdopt || len(saved.Pending) != 0 {
+		t.Errorf("expected the answers to be saved, got %+v", saved.Session)
+	}
+}
+
+func TestConsult_ElicitationDeclined(t *testing.T) {
+	session := connect(t, func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		return &mcp.ElicitResult{Action: "decline"}, nil
+	})
+
+	output := callConsult(t, session, t.TempDir())
+	if len(output.Answers) != 0 || !strings.Contains(output.Guidance, "declined") {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+}
+
+func TestConsult_WithoutElicitation(t *testing.T) {
+	output := callConsult(t, connect(t, nil), t.TempDir())
+	if len(output.Answers) != 0 || !strings.Contains(output.Guidance, "Present each question") {
+		t.Fatalf("expected the guidance fallback, got %+v", output)
+	}
+}
+
+func TestChecklist_Elicitation(t *testing.T) {
+	session := connect(t, func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"answer": "not addressed", "notes": "Next quarter."}}, nil
+	})
+
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "checklist",
+		Arguments: ChecklistInput{Project: "billing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output ChecklistOutput
+	data, _ := json.Marshal(res.StructuredContent)
+	if err := json.Unmarshal(data, &output); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range output.Items {
+		if item.Answer != "not addressed" || item.Notes != "Next quarter." {
+			t.Fatalf("expected the user's answer on every item, got %+v", item)
+		}
+	}
+	if !strings.Contains(output.Guidance, "Do NOT ask them again") {
+		t.Errorf("unexpected guidance: %q", output.Guidance)
+	}
+}
@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Evidence statuses attached to checklist items when a path is scanned.
+const (
+	StatusDetected    = "detected"
+	StatusPartial     = "partial"
+	StatusNotDetected = "not detected"
+)
+
+// maxEvidenceFiles bounds the walk so a huge monorepo cannot stall the
+// checklist; evidence files sit near the top of a repo anyway.
+const maxEvidenceFiles = 50000
+
+// maxEvidenceRead bounds how much of a single file is searched for content
+// markers such as probes or scanner invocations.
+const maxEvidenceRead = 256 << 10
+
+// repoFiles is the set of files found under a scanned path, with lazily
+// read contents for the detectors that need to look inside them.
+type repoFiles struct {
+	root  string
+	paths []string
+	cache map[string]string
+}
+
+func walkRepoFiles(root string) (*repoFiles, error) {
+	r := &repoFiles{root: root, cache: map[string]string{}}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(r.paths) >= maxEvidenceFiles {
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(root, p)
+		r.paths = append(r.paths, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(r.paths)
+	return r, err
+}
+
+// read returns up to maxEvidenceRead bytes of rel, lowercased.
+func (r *repoFiles) read(rel string) string {
+	if s, ok := r.cache[rel]; ok {
+		return s
+	}
+	f, err := os.Open(filepath.Join(r.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, maxEvidenceRead)
+	n, _ := f.Read(buf)
+	s := strings.ToLower(string(buf[:n]))
+	r.cache[rel] = s
+	return s
+}
+
+// match returns files whose path matches any of the patterns.
+func (r *repoFiles) match(patterns ...*regexp.Regexp) []string {
+	var out []string
+	for _, p := range r.paths {
+		for _, re := range patterns {
+			if re.MatchString(p) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// containing returns the files among candidates whose contents match re.
+func (r *repoFiles) containing(candidates []string, re *regexp.Regexp) []string {
+	var out []string
+	for _, p := range candidates {
+		if re.MatchString(r.read(p)) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+var (
+	ciConfigFiles = regexp.MustCompile(`(?i)^(\.github/workflows/[^/]+\.ya?ml|\.gitlab-ci\.ya?ml|\.circleci/config\.ya?ml|jenkinsfile|azure-pipelines\.ya?ml|\.travis\.ya?ml|bitbucket-pipelines\.ya?ml|\.buildkite/.+\.ya?ml|\.drone\.ya?ml)$`)
+	hookFiles     = regexp.MustCompile(`(?i)^(lefthook\.ya?ml|\.pre-commit-config\.ya?ml|\.husky/[^/]+)$`)
+	testFiles     = regexp.MustCompile(`(?i)(_test\.go|(^|/)test_[^/]+\.py|_test\.py|\.(test|spec)\.[jt]sx?|Test\.java|_spec\.rb)$|(^|/)tests?/[^/]+$`)
+	yamlFiles     = regexp.MustCompile(`(?i)\.ya?ml$`)
+	dockerfiles   = regexp.MustCompile(`(?i)(^|/)(dockerfile|containerfile)(\.[^/]*)?$`)
+	composeFiles  = regexp.MustCompile(`(?i)(^|/)(docker-)?compose(\.[^/]*)?\.ya?ml$`)
+	helmCharts    = regexp.MustCompile(`(?i)(^|/)chart\.yaml$`)
+	terraform     = regexp.MustCompile(`(?i)\.tf$`)
+	paasConfigs   = regexp.MustCompile(`(?i)(^|/)(procfile|fly\.toml|vercel\.json|netlify\.toml|app\.ya?ml|render\.ya?ml|\.goreleaser\.ya?ml|skaffold\.ya?ml|serverless\.ya?ml)$`)
+	monitorFiles  = regexp.MustCompile(`(?i)(^|/)(prometheus\.ya?ml|alertmanager\.ya?ml|[^/]*alerts?[^/]*\.ya?ml|[^/]*rules\.ya?ml|grafana/.+\.json|dashboards?/.+\.json|datadog\.ya?ml|newrelic\.ya?ml|sentry\.properties)$`)
+	oncallFiles   = regexp.MustCompile(`(?i)(^|/)([^/]*(on-?call|pagerduty|opsgenie|escalation|sla|slo)[^/]*\.(md|ya?ml|json|txt))$`)
+	codeowners    = regexp.MustCompile(`(?i)(^|/)codeowners$`)
+	securityFiles = regexp.MustCompile(`(?i)^(\.github/)?(security\.md|dependabot\.ya?ml)$|(^|/)(renovate\.json5?|\.renovaterc(\.json)?|\.snyk|\.trivyignore|osv-scanner\.toml)$`)
+	readmes       = regexp.MustCompile(`(?i)^readme(\.[a-z]+)?$`)
+	docFiles      = regexp.MustCompile(`(?i)^(docs?/.+\.(md|rst|adoc|txt)|contributing\.md|architecture\.md|mkdocs\.ya?ml)$`)
+	runbookFiles  = regexp.MustCompile(`(?i)(^|/)[^/]*(runbook|playbook|incident|rollback)[^/]*(\.(md|rst|txt)|/.+)$`)
+
+	probeContent    = regexp.MustCompile(`livenessprobe|readinessprobe|startupprobe`)
+	healthcheck     = regexp.MustCompile(`(?m)^\s*healthcheck\b`)
+	scannerContent  = regexp.MustCompile(`govulncheck|codeql|trivy|snyk|pip-audit|npm audit|cargo audit|cargo-audit|osv-scanner|gosec|semgrep|dependency-review`)
+	deployContent   = regexp.MustCompile(`\bdeploy|\brelease|goreleaser|docker push|helm upgrade|kubectl apply|terraform apply`)
+	k8sWorkload     = regexp.MustCompile(`(?m)^kind:\s*(deployment|statefulset|daemonset|cronjob|service)\b`)
+	oncallMentioned = regexp.MustCompile(`on-?call|pagerduty|opsgenie|escalation policy`)
+)
+
+// checklistDetectors maps built-in checklist categories to evidence
+// detectors. Categories without a detector (e.g. ones added by a project)
+// carry no status.
+var checklistDetectors = map[string]func(r *repoFiles) (string, []string){
+	"Automated tests / CI": func(r *repoFiles) (string, []string) {
+		ci := r.match(ciConfigFiles)
+		hooks := r.match(hookFiles)
+		tests := r.match(testFiles)
+		evidence := append(append(ci, hooks...), limit(tests, 5)...)
+		switch {
+		case len(ci) > 0 && len(tests) > 0:
+			return StatusDetected, evidence
+		case len(evidence) > 0:
+			return StatusPartial, evidence
+		}
+		return StatusNotDetected, nil
+	},
+	"Monitoring": func(r *repoFiles) (string, []string) {
+		yamls := r.match(yamlFiles)
+		evidence := r.containing(yamls, probeContent)
+		evidence = append(evidence, r.containing(r.match(dockerfiles), healthcheck)...)
+		evidence = append(evidence, r.match(monitorFiles)...)
+		if len(evidence) > 0 {
+			return StatusDetected, dedupe(evidence)
+		}
+		return StatusNotDetected, nil
+	},
+	"On-call coverage / SLAs": func(r *repoFiles) (string, []string) {
+		evidence := r.match(oncallFiles)
+		evidence = append(evidence, r.containing(r.match(docFiles, runbookFiles), oncallMentioned)...)
+		if len(evidence) > 0 {
+			return StatusDetected, dedupe(evidence)
+		}
+		if owners := r.match(codeowners); len(owners) > 0 {
+			return StatusPartial, owners
+		}
+		return StatusNotDetected, nil
+	},
+	"Security audit / automated scans": func(r *repoFiles) (string, []string) {
+		policy := r.match(securityFiles)
+		scans := r.containing(r.match(ciConfigFiles, hookFiles), scannerContent)
+		evidence := dedupe(append(policy, scans...))
+		switch {
+		case len(scans) > 0 || containsAny(policy, "dependabot", "renovate", "snyk"):
+			return StatusDetected, evidence
+		case len(evidence) > 0:
+			return StatusPartial, evidence
+		}
+		return StatusNotDetected, nil
+	},
+	"Deployment pipeline / CD": func(r *repoFiles) (string, []string) {
+		pipelines := r.containing(r.match(ciConfigFiles), deployContent)
+		artifacts := r.match(dockerfiles, composeFiles, helmCharts, terraform, paasConfigs)
+		artifacts = append(artifacts, r.containing(r.match(yamlFiles), k8sWorkload)...)
+		evidence := dedupe(append(pipelines, limit(artifacts, 10)...))
+		switch {
+		case len(pipelines) > 0:
+			return StatusDetected, evidence
+		case len(evidence) > 0:
+			return StatusPartial, evidence
+		}
+		return StatusNotDetected, nil
+	},
+	"Documentation / runbooks": func(r *repoFiles) (string, []string) {
+		readme := r.match(readmes)
+		runbooks := r.match(runbookFiles)
+		docs := r.match(docFiles)
+		evidence := dedupe(append(append(readme, runbooks...), limit(docs, 5)...))
+		switch {
+		case len(readme) > 0 && len(runbooks) > 0:
+			return StatusDetected, evidence
+		case len(evidence) > 0:
+			return StatusPartial, evidence
+		}
+		return StatusNotDetected, nil
+	},
+}
+
+// detectEvidence fills in Status and Evidence for each item with a
+// detector, based on the files under root.
+func detectEvidence(root string, items []ChecklistItem) error {
+	r, err := walkRepoFiles(root)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if detect, ok := checklistDetectors[items[i].Category]; ok {
+			items[i].Status, items[i].Evidence = detect(r)
+		}
+	}
+	return nil
+}
+
+func limit(s []string, n int) []string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func dedupe(s []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// containsAny reports whether any of paths has a base name containing one of
+// the substrings, case-insensitively.
+func containsAny(paths []string, substrings ...string) bool {
+	for _, p := range paths {
+		base := strings.ToLower(path.Base(p))
+		for _, s := range substrings {
+			if strings.Contains(base, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func detectFor(t *testing.T, files map[string]string) map[string]ChecklistItem {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+
+	var items []ChecklistItem
+	for category := range checklistDetectors {
+		items = append(items, ChecklistItem{Category: category})
+	}
+	if err := detectEvidence(dir, items); err != nil {
+		t.Fatal(err)
+	}
+
+	byCategory := map[string]ChecklistItem{}
+	for _, item := range items {
+		byCategory[item.Category] = item
+	}
+	return byCategory
+}
+
+func TestDetectEvidence_EmptyProject(t *testing.T) {
+	items := detectFor(t, map[string]string{"main.go": "package main\n"})
+
+	for category, item := range items {
+		if item.Status != StatusNotDetected {
+			t.Errorf("%s: expected %q, got %q (%v)", category, StatusNotDetected, item.Status, item.Evidence)
+		}
+	}
+}
+
+func TestDetectEvidence_WellEquippedProject(t *testing.T) {
+	items := detectFor(t, map[string]string{
+		".github/workflows/ci.yml":      "jobs:\n  check:\n    steps:\n      - run: govulncheck ./...\n      - run: go test ./...\n",
+		".github/workflows/release.yml": "on:\n  push:\n    tags: ['v*']\njobs:\n  release:\n    steps:\n      - run: goreleaser release\n",
+		"main_test.go":                  "package main\n",
+		"deploy/k8s.yaml":               "kind: Deployment\nspec:\n  containers:\n    - livenessProbe:\n        httpGet:\n          path: /healthz\n",
+		"SECURITY.md":                   "Report vulnerabilities to security@example.com\n",
+		"docs/oncall.md":                "Escalate via PagerDuty.\n",
+		"README.md":                     "# app\n",
+		"docs/runbooks/rollback.md":     "# Rolling back\n",
+	})
+
+	want := map[string][]string{
+		"Automated tests / CI":             {".github/workflows/ci.yml", "main_test.go"},
+		"Monitoring":                       {"deploy/k8s.yaml"},
+		"On-call coverage / SLAs":          {"docs/oncall.md"},
+		"Security audit / automated scans": {"SECURITY.md", ".github/workflows/ci.yml"},
+		"Deployment pipeline / CD":         {".github/workflows/release.yml"},
+		"Documentation / runbooks":         {"README.md", "docs/runbooks/rollback.md"},
+	}
+	for category, files := range want {
+		item := items[category]
+		if item.Status != StatusDetected {
+			t.Errorf("%s: expected %q, got %q (%v)", category, StatusDetected, item.Status, item.Evidence)
+		}
+		for _, f := range files {
+			if !slices.Contains(item.Evidence, f) {
+				t.Errorf("%s: expected evidence %s, got %v", category, f, item.Evidence)
+			}
+		}
+	}
+}
+
+func TestDetectEvidence_Partial(t *testing.T) {
+	items := detectFor(t, map[string]string{
+		"README.md":       "# app\n",
+		"Dockerfile":      "FROM scratch\n",
+		"CODEOWNERS":      "* @team\n",
+		"pkg/lib_test.go": "package pkg\n",
+	})
+
+	for _, category := range []string{"Automated tests / CI", "On-call coverage / SLAs", "Deployment pipeline / CD", "Documentation / runbooks"} {
+		if items[category].Status != StatusPartial {
+			t.Errorf("%s: expected %q, got %q (%v)", category, StatusPartial, items[category].Status, items[category].Evidence)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// stagedTree labels the head side of a gate check.
+const stagedTree = "staged"
+
+type GateInput struct {
+	Path string `json:"path,omitempty" jsonschema:"directory inside the git repository to check (default .)"`
+}
+
+// BudgetViolation is a metric that grew more than its budget allows.
+type BudgetViolation struct {
+	// Scope is a language name, or "project" for the languages without a
+	// budget of their own.
+	Scope   string  `json:"scope"`
+	Metric  string  `json:"metric"`
+	Delta   float64 `json:"delta"`
+	Budget  float64 `json:"budget"`
+	Message string  `json:"message"`
+}
+
+type GateOutput struct {
+	Passed     bool              `json:"passed"`
+	Delta      CompareOutput     `json:"delta"`
+	Budget     BudgetConfig      `json:"budget"`
+	Violations []BudgetViolation `json:"violations,omitempty"`
+	Guidance   string            `json:"guidance"`
+}
+
+// HandleGate compares the staged tree with HEAD and checks the growth
+// against the budgets in .mtb.yaml.
+func HandleGate(ctx context.Context, req *mcp.CallToolRequest, input GateInput) (*mcp.CallToolResult, GateOutput, error) {
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[GateOutput]("invalid path: " + err.Error())
+	}
+	if _, err := runGit(ctx, absPath, "rev-parse", "--show-toplevel"); err != nil {
+		return ErrResult[GateOutput]("gate requires a git repository: " + err.Error())
+	}
+
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[GateOutput](err.Error())
+	}
+	staged, err := runGit(ctx, absPath, "write-tree")
+	if err != nil {
+		return ErrResult[GateOutput]("reading the staged tree: " + err.Error())
+	}
+
+	model, err := resolveCocomo(CocomoModel{}, cfg.Cocomo)
+	if err != nil {
+		return ErrResult[GateOutput](err.Error())
+	}
+	analyze := func(ref string) (*StatsOutput, error) {
+		return analyzeRef(ctx, absPath, ref, model, cfg.Stats.ExcludeDir, cfg.Stats.ExcludeExtensions, cfg.Stats.IncludeExtensions)
+	}
+	// Before the first commit there is no HEAD and everything staged is new.
+	before := &StatsOutput{}
+	var after *StatsOutput
+	var beforeErr, afterErr error
+	var wg sync.WaitGroup
+	if _, err := runGit(ctx, absPath, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		wg.Go(func() { before, beforeErr = analyze("HEAD") })
+	}
+	wg.Go(func() { after, afterErr = analyze(staged) })
+	wg.Wait()
+	if beforeErr != nil {
+		return ErrResult[GateOutput]("analysis of HEAD failed: " + beforeErr.Error())
+	}
+	if afterErr != nil {
+		return ErrResult[GateOutput]("analysis of the staged tree failed: " + afterErr.Error())
+	}
+
+	output := GateOutput{Delta: diffStats(before, after, model), Budget: cfg.Budget}
+	output.Delta.Base = "HEAD"
+	output.Delta.Head = stagedTree
+	output.Delta.Table = renderDeltaTable(output.Delta)
+	output.Violations = checkBudget(output.Delta, cfg.Budget)
+	output.Passed = len(output.Violations) == 0
+
+	var summary strings.Builder
+	switch {
+	case !output.Passed:
+		summary.WriteString("Complexity budget exceeded:\n")
+		for _, v := range output.Violations {
+			fmt.Fprintf(&summary, "- %s\n", v.Message)
+		}
+		output.Guidance = "IMPORTANT: The staged changes exceed the project's complexity budget. Present the violations and the " +
+			"before/after table to the user. Discuss whether the change can be simplified or split into smaller commits. " +
+			"Do NOT raise the budget in .mtb.yaml without the user's explicit approval."
+	case cfg.Budget.isZero():
+		summary.WriteString("No complexity budget is configured; add a budget section to .mtb.yaml to enforce one.\n")
+		output.Guidance = "No budget is configured, so the gate always passes. Present the before/after table to the user " +
+			"and ask whether they want to set a budget in .mtb.yaml."
+	default:
+		summary.WriteString("Staged changes are within the complexity budget.\n")
+		output.Guidance = "The staged changes are within budget. Present the before/after table to the user."
+	}
+	fmt.Fprintf(&summary, "\nHEAD → staged:\n\n%s", output.Delta.Table)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary.String()}},
+	}, output, nil
+}
+
+func (b BudgetConfig) isZero() bool {
+	return b.Budget == Budget{} && len(b.Languages) == 0
+}
+
+// checkBudget returns the metrics in delta that exceed their budgets.
+// Languages with their own budget are checked alone and subtracted from the
+// project-wide pool. COCOMO cannot be split, so the pool's cost is estimated
+// afresh from the code left in it.
+func checkBudget(delta CompareOutput, budget BudgetConfig) []BudgetViolation {
+	var violations []BudgetViolation
+	pool := delta.Total
+	split := false
+	for _, l := range delta.Languages {
+		lb, ok := languageBudget(budget.Languages, l.Name)
+		if !ok {
+			continue
+		}
+		violations = append(violations, exceeded(l.Name, l, lb, delta.Cocomo.Currency)...)
+		split = true
+		pool.Code.Before -= l.Code.Before
+		pool.Code.After -= l.Code.After
+		pool.Complexity.Delta -= l.Complexity.Delta
+	}
+	if split {
+		pool.EstimatedCost.Before = delta.Cocomo.cost(pool.Code.Before)
+		pool.EstimatedCost.After = delta.Cocomo.cost(pool.Code.After)
+	}
+	pool.Code.Delta = pool.Code.After - pool.Code.Before
+	pool.EstimatedCost.Delta = pool.EstimatedCost.After - pool.EstimatedCost.Before
+	violations = append(violations, exceeded("project", pool, budget.Budget, delta.Cocomo.Currency)...)
+	return violations
+}
+
+// languageBudget looks name up case-insensitively, so "go" in the config
+// matches scc's "Go".
+func languageBudget(budgets map[string]Budget, name string) (Budget, bool) {
+	keys := make([]string, 0, len(budgets))
+	for k := range budgets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.EqualFold(k, name) {
+			return budgets[k], true
+		}
+	}
+	return Budget{}, false
+}
+
+func exceeded(scope string, d LanguageDelta, b Budget, currency string) []BudgetViolation {
+	var violations []BudgetViolation
+	check := func(metric string, delta, limit float64, format func(float64) string) {
+		if limit > 0 && delta > limit {
+			violations = append(violations, BudgetViolation{
+				Scope:   scope,
+				Metric:  metric,
+				Delta:   delta,
+				Budget:  limit,
+				Message: fmt.Sprintf("%s %s grew by %s, over the budget of %s", scope, metric, format(delta), format(limit)),
+			})
+		}
+	}
+	count := func(v float64) string { return fmt.Sprintf("%.0f", v) }
+	check("complexity", float64(d.Complexity.Delta), float64(b.Complexity), count)
+	check("code lines", float64(d.Code.Delta), float64(b.Code), count)
+	check("estimated cost", d.EstimatedCost.Delta, b.Cost, func(v float64) string { return FormatMoney(v, currency) })
+	return violations
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const complexGo = "package main\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\tif x < 0 {\n\t\treturn -1\n\t}\n\treturn 0\n}\n"
+
+func TestHandleGate_NoBudget(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n"})
+	writeFiles(t, dir, map[string]string{"f.go": complexGo})
+	gitT(t, dir, "add", ".")
+
+	_, output, err := HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !output.Passed {
+		t.Fatal("expected the gate to pass without a budget")
+	}
+	if output.Delta.Total.Complexity.Delta != 2 {
+		t.Fatalf("expected the staged complexity delta, got %+v", output.Delta.Total)
+	}
+}
+
+func TestHandleGate_OnlyStagedChangesCount(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".mtb.yaml": "budget:\n  complexity: 1\n",
+		"main.go":   "package main\n",
+	})
+	// Unstaged work is ignored.
+	writeFiles(t, dir, map[string]string{"f.go": complexGo})
+
+	_, output, err := HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !output.Passed || output.Delta.Total.Code.Delta != 0 {
+		t.Fatalf("expected unstaged changes to be ignored, got %+v", output.Delta.Total)
+	}
+
+	gitT(t, dir, "add", "f.go")
+	_, output, err = HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Passed || len(output.Violations) != 1 {
+		t.Fatalf("expected one violation, got %+v", output.Violations)
+	}
+	v := output.Violations[0]
+	if v.Scope != "project" || v.Metric != "complexity" || v.Delta != 2 || v.Budget != 1 {
+		t.Fatalf("unexpected violation: %+v", v)
+	}
+	if v.Message != "project complexity grew by 2, over the budget of 1" {
+		t.Fatalf("unexpected message: %q", v.Message)
+	}
+}
+
+func TestHandleGate_LanguageOverride(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".mtb.yaml": "budget:\n  complexity: 1\n  languages:\n    go:\n      complexity: 5\n    python:\n      code: 1\n",
+		"main.go":   "package main\n",
+	})
+	writeFiles(t, dir, map[string]string{
+		"f.go":    complexGo,
+		"tool.py": "def f():\n    return 1\n",
+	})
+	gitT(t, dir, "add", ".")
+
+	_, output, err := HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Go's complexity fits its own budget and no longer counts toward the
+	// project's; Python exceeds its code budget.
+	if len(output.Violations) != 1 {
+		t.Fatalf("expected one violation, got %+v", output.Violations)
+	}
+	if v := output.Violations[0]; v.Scope != "Python" || v.Metric != "code lines" {
+		t.Fatalf("unexpected violation: %+v", v)
+	}
+}
+
+func TestHandleGate_LanguageOverrideCost(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		".mtb.yaml": "budget:\n  cost: 1\n  languages:\n    go:\n      code: 5000\n",
+		"tool.py":   strings.Repeat("x = 1\n", 2000),
+	})
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\n" + strings.Repeat("var _ = 1\n", 1000)})
+	gitT(t, dir, "add", ".")
+
+	_, output, err := HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only Go grew, and Go is budgeted on its own, so the rest of the
+	// project's cost is unchanged even though the whole tree's grew by more
+	// than Go's own estimate.
+	if !output.Passed {
+		t.Fatalf("expected growth in Go alone to leave the project cost budget alone, got %+v", output.Violations)
+	}
+}
+
+func TestHandleGate_InitialCommit(t *testing.T) {
+	dir := t.TempDir()
+	gitT(t, dir, "init", "-q")
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+	gitT(t, dir, "add", ".")
+
+	result, output, err := HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
+	if output.Delta.Total.Code.Before != 0 || output.Delta.Total.Code.After != 1 {
+		t.Fatalf("expected everything staged to be new, got %+v", output.Delta.Total)
+	}
+}
+
+func TestHandleGate_NotGitRepo(t *testing.T) {
+	result, _, _ := HandleGate(context.Background(), &mcp.CallToolRequest{}, GateInput{Path: t.TempDir()})
+	if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "git repository") {
+		t.Fatal("expected an error outside a git repository")
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initGitRepo creates a git repository in a temp dir with the given files
+// committed, and returns its path.
+func initGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	gitT(t, dir, "init", "-q")
+	writeFiles(t, dir, files)
+	gitCommitAll(t, dir, "initial")
+	return dir
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func gitCommitAll(t *testing.T, dir, msg string) {
+	t.Helper()
+	gitT(t, dir, "add", "-A")
+	gitT(t, dir, "commit", "-q", "--allow-empty", "-m", msg)
+}
+
+func gitT(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=mtb", "-c", "user.email=mtb@example.com", "-c", "commit.gpgsign=false"}, args...)
+	out, err := runGit(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestExportTree(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"main.go":    "package main\n",
+		"sub/lib.go": "package sub\n",
+	})
+	writeFiles(t, dir, map[string]string{"untracked.go": "package main\n"})
+
+	tree, cleanup, err := exportTree(context.Background(), dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for _, name := range []string{"main.go", "sub/lib.go"} {
+		if _, err := os.Stat(filepath.Join(tree, name)); err != nil {
+			t.Errorf("expected %s in exported tree: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tree, "untracked.go")); err == nil {
+		t.Error("expected untracked file to be absent from exported tree")
+	}
+}
+
+func TestExportTree_Subdirectory(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"main.go":    "package main\n",
+		"sub/lib.go": "package sub\n",
+	})
+
+	tree, cleanup, err := exportTree(context.Background(), filepath.Join(dir, "sub"), "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	if _, err := os.Stat(filepath.Join(tree, "lib.go")); err != nil {
+		t.Errorf("expected lib.go at the root of a subdirectory export: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tree, "main.go")); err == nil {
+		t.Error("expected files outside the subdirectory to be absent")
+	}
+}
+
+func TestExportTree_BadRef(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n"})
+
+	if _, _, err := exportTree(context.Background(), dir, "no-such-ref"); err == nil {
+		t.Fatal("expected error for unknown ref")
+	}
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type HotspotsInput struct {
+	Path              string   `json:"path,omitempty" jsonschema:"directory inside the git repository to analyze (default .)"`
+	Since             string   `json:"since,omitempty" jsonschema:"only count commits newer than this git date, e.g. 6 months ago or 2024-01-01 (default 1 year ago)"`
+	MaxCommits        int      `json:"max_commits,omitempty" jsonschema:"only count the N most recent commits in the window"`
+	TopN              int      `json:"top_n,omitempty" jsonschema:"number of hotspots to return (default 10)"`
+	ExcludeDir        []string `json:"exclude_dir,omitempty" jsonschema:"directories to exclude from analysis"`
+	ExcludeExtensions []string `json:"exclude_ext,omitempty" jsonschema:"file extensions to exclude (e.g. min.js)"`
+	IncludeExtensions []string `json:"include_ext,omitempty" jsonschema:"only include these file extensions"`
+}
+
+// Hotspot is a file's change history joined with its current complexity.
+type Hotspot struct {
+	Location     string    `json:"location"`
+	Language     string    `json:"language"`
+	Commits      int       `json:"commits"`
+	LinesAdded   int64     `json:"linesAdded"`
+	LinesDeleted int64     `json:"linesDeleted"`
+	Code         int64     `json:"code"`
+	Complexity   int64     `json:"complexity"`
+	LastChanged  time.Time `json:"lastChanged"`
+	// Score is commits × complexity, so a file ranks high only when it is
+	// both complex and frequently changed.
+	Score int64 `json:"score"`
+}
+
+type HotspotsOutput struct {
+	Since string `json:"since"`
+	// Commits is the number of commits in the window that touched path.
+	Commits  int       `json:"commits"`
+	Hotspots []Hotspot `json:"hotspots"`
+	Table    string    `json:"table"`
+	Guidance string    `json:"guidance"`
+}
+
+// defaultHotspotsSince is the history window when neither the input nor
+// the config sets one.
+const defaultHotspotsSince = "1 year ago"
+
+// fileChurn is one file's change history within the window.
+type fileChurn struct {
+	commits     int
+	added       int64
+	deleted     int64
+	lastChanged time.Time
+}
+
+func HandleHotspots(ctx context.Context, req *mcp.CallToolRequest, input HotspotsInput) (*mcp.CallToolResult, HotspotsOutput, error) {
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[HotspotsOutput]("invalid path: " + err.Error())
+	}
+	if input.MaxCommits < 0 || input.TopN < 0 {
+		return ErrResult[HotspotsOutput]("max_commits and top_n must not be negative")
+	}
+	if _, err := runGit(ctx, absPath, "rev-parse", "--show-toplevel"); err != nil {
+		return ErrResult[HotspotsOutput]("hotspots requires a git repository: " + err.Error())
+	}
+
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[HotspotsOutput](err.Error())
+	}
+	since := input.Since
+	if since == "" {
+		since = cfg.Hotspots.Since
+	}
+	if since == "" {
+		since = defaultHotspotsSince
+	}
+	topN := input.TopN
+	if topN == 0 {
+		topN = 10
+	}
+
+	churn, commits, err := gitChurn(ctx, absPath, since, input.MaxCommits)
+	if err != nil {
+		return ErrResult[HotspotsOutput]("reading history failed: " + err.Error())
+	}
+	stats, err := RunSCC(ctx, absPath, nil, true,
+		orDefault(input.ExcludeDir, cfg.Stats.ExcludeDir),
+		orDefault(input.ExcludeExtensions, cfg.Stats.ExcludeExtensions),
+		orDefault(input.IncludeExtensions, cfg.Stats.IncludeExtensions))
+	if err != nil {
+		return ErrResult[HotspotsOutput]("analysis failed: " + err.Error())
+	}
+
+	output := HotspotsOutput{Since: since, Commits: commits, Hotspots: rankHotspots(churn, stats.Files, topN)}
+	output.Table = renderHotspotsTable(output.Hotspots)
+	if len(output.Hotspots) == 0 {
+		output.Guidance = fmt.Sprintf("No complex file under %s changed since %s. Tell the user there are no hotspots in this window; "+
+			"a longer window (since) may find some.", absPath, since)
+	} else {
+		output.Guidance = "IMPORTANT: Present the ranked hotspots to the user. These files are both complex and frequently changed, " +
+			"so they are where bugs concentrate and where simplifying pays off most. " +
+			"Before adding more complexity to one of them, discuss splitting it up or covering it with tests first. " +
+			"Do NOT start refactoring on your own — the user decides which hotspots are worth the effort."
+	}
+
+	summary := fmt.Sprintf("Hotspots since %s (%d commits):\n\n%s", since, commits, output.Table)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+	}, output, nil
+}
+
+// gitChurn reads the commits to dir since the given git date and returns
+// each file's history, keyed by its path relative to dir with forward
+// slashes, and the number of commits read. Renames count as a deletion and
+// an addition, so history before a rename is not carried over.
+func gitChurn(ctx context.Context, dir, since string, maxCommits int) (map[string]*fileChurn, int, error) {
+	args := []string{"-c", "core.quotePath=false", "log", "--numstat", "--no-renames", "--relative",
+		"--format=%x00%ct", "--since=" + since}
+	if maxCommits > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(maxCommits))
+	}
+	out, err := runGit(ctx, dir, append(args, "--", ".")...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	churn := map[string]*fileChurn{}
+	commits := 0
+	var when time.Time
+	for _, line := range strings.Split(out, "\n") {
+		if ts, ok := strings.CutPrefix(line, "\x00"); ok {
+			secs, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("unexpected commit header %q", ts)
+			}
+			when = time.Unix(secs, 0).UTC()
+			commits++
+			continue
+		}
+		added, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		deleted, name, ok := strings.Cut(rest, "\t")
+		if !ok {
+			continue
+		}
+		f := churn[name]
+		if f == nil {
+			// git log lists the newest commit first.
+			f = &fileChurn{lastChanged: when}
+			churn[name] = f
+		}
+		f.commits++
+		// Binary files report "-" for both counts.
+		if n, err := strconv.ParseInt(added, 10, 64); err == nil {
+			f.added += n
+		}
+		if n, err := strconv.ParseInt(deleted, 10, 64); err == nil {
+			f.deleted += n
+		}
+	}
+	return churn, commits, nil
+}
+
+// rankHotspots joins churn with the current files and returns the n with
+// the highest score. Files that are unchanged in the window or have no
+// complexity are left out.
+func rankHotspots(churn map[string]*fileChurn, files []FileSummary, n int) []Hotspot {
+	var hotspots []Hotspot
+	for _, f := range files {
+		c := churn[f.Location]
+		if c == nil || f.Complexity == 0 {
+			continue
+		}
+		hotspots = append(hotspots, Hotspot{
+			Location:     f.Location,
+			Language:     f.Language,
+			Commits:      c.commits,
+			LinesAdded:   c.added,
+			LinesDeleted: c.deleted,
+			Code:         f.Code,
+			Complexity:   f.Complexity,
+			LastChanged:  c.lastChanged,
+			Score:        int64(c.commits) * f.Complexity,
+		})
+	}
+	sort.SliceStable(hotspots, func(i, j int) bool {
+		a, b := hotspots[i], hotspots[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if churnA, churnB := a.LinesAdded+a.LinesDeleted, b.LinesAdded+b.LinesDeleted; churnA != churnB {
+			return churnA > churnB
+		}
+		return a.Location < b.Location
+	})
+	if n < len(hotspots) {
+		hotspots = hotspots[:n]
+	}
+	return hotspots
+}
+
+// renderHotspotsTable renders a markdown table of the ranked hotspots.
+func renderHotspotsTable(hotspots []Hotspot) string {
+	var b strings.Builder
+	b.WriteString("| # | File | Commits | Lines changed | Complexity | Score |\n")
+	b.WriteString("|---|------|---------|---------------|------------|-------|\n")
+	for i, h := range hotspots {
+		fmt.Fprintf(&b, "| %d | %s | %d | +%d -%d | %d | %d |\n", i+1, h.Location, h.Commits, h.LinesAdded, h.LinesDeleted, h.Complexity, h.Score)
+	}
+	return b.String()
+}
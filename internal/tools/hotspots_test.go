@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHotspots(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{
+		"app/complex.go": complexGo,
+		"app/simple.go":  "package app\n\nfunc g() int {\n\treturn 1\n}\n",
+		"app/flat.go":    "package app\n\nvar v = 1\n",
+		"README.md":      "# app\n",
+	})
+	for i := range 3 {
+		writeFiles(t, dir, map[string]string{
+			"app/complex.go": complexGo + fmt.Sprintf("\nvar c%d = %d\n", i, i),
+			"app/flat.go":    fmt.Sprintf("package app\n\nvar v = %d\n", i+2),
+			"README.md":      fmt.Sprintf("# app %d\n", i),
+		})
+		gitCommitAll(t, dir, fmt.Sprintf("change %d", i))
+	}
+	writeFiles(t, dir, map[string]string{"app/simple.go": "package app\n\nfunc g() int {\n\tif true {\n\t\treturn 2\n\t}\n\treturn 1\n}\n"})
+	gitCommitAll(t, dir, "simple")
+
+	_, output, err := HandleHotspots(context.Background(), nil, HotspotsInput{Path: dir + "/app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Commits != 5 || output.Since != defaultHotspotsSince {
+		t.Fatalf("expected 5 commits since %s, got %d since %s", defaultHotspotsSince, output.Commits, output.Since)
+	}
+	if len(output.Hotspots) != 2 {
+		t.Fatalf("expected the two files with complexity, got %+v", output.Hotspots)
+	}
+	top := output.Hotspots[0]
+	if top.Location != "complex.go" || top.Commits != 4 || top.Score != 4*top.Complexity || top.LinesAdded == 0 || top.LinesDeleted != 2 {
+		t.Fatalf("expected complex.go to rank first, got %+v", top)
+	}
+	if output.Hotspots[1].Location != "simple.go" || output.Hotspots[1].Commits != 2 {
+		t.Fatalf("expected simple.go second, got %+v", output.Hotspots[1])
+	}
+	if !strings.Contains(output.Table, "| 1 | complex.go | 4 |") || !strings.Contains(output.Guidance, "Present the ranked hotspots") {
+		t.Errorf("unexpected table or guidance:\n%s\n%s", output.Table, output.Guidance)
+	}
+
+	_, output, err = HandleHotspots(context.Background(), nil, HotspotsInput{Path: dir, MaxCommits: 1, TopN: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Commits != 1 || len(output.Hotspots) != 1 || output.Hotspots[0].Location != "app/simple.go" {
+		t.Fatalf("expected only the latest commit, got %+v", output)
+	}
+}
+
+func TestHotspots_Window(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": complexGo, ".mtb.yaml": "hotspots:\n  since: 2099-01-01\n"})
+
+	_, output, err := HandleHotspots(context.Background(), nil, HotspotsInput{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Since != "2099-01-01" || output.Commits != 0 || len(output.Hotspots) != 0 || !strings.Contains(output.Guidance, "no hotspots") {
+		t.Fatalf("expected the configured window to exclude every commit, got %+v", output)
+	}
+}
+
+func TestHotspots_Errors(t *testing.T) {
+	result, _, _ := HandleHotspots(context.Background(), nil, HotspotsInput{Path: t.TempDir()})
+	if result == nil || !result.IsError {
+		t.Error("expected an error outside a git repository")
+	}
+	result, _, _ = HandleHotspots(context.Background(), nil, HotspotsInput{Path: t.TempDir(), TopN: -1})
+	if result == nil || !result.IsError {
+		t.Error("expected an error for a negative top_n")
+	}
+}
@@ -0,0 +1,686 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LockfileSummary counts the packages a lockfile resolves, split into the
+// project's direct dependencies and everything they pull in.
+type LockfileSummary struct {
+	Ecosystem  string `json:"ecosystem"`
+	Lockfile   string `json:"lockfile"`
+	Packages   int    `json:"packages"`
+	Direct     int    `json:"direct"`
+	Transitive int    `json:"transitive"`
+	// Graph reports whether the lockfile records which package requires
+	// which. go.sum does not, so Go lockfiles have counts but no subtrees.
+	Graph bool `json:"graph"`
+	// Subtrees are the direct dependencies with the most transitive
+	// packages, heaviest first.
+	Subtrees []DependencySubtree `json:"subtrees,omitempty"`
+}
+
+// DependencySubtree is the transitive weight of one direct dependency.
+type DependencySubtree struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Transitive counts the distinct packages the dependency pulls in.
+	Transitive int `json:"transitive"`
+	// Exclusive counts the ones no other direct dependency pulls in: what
+	// removing this dependency would drop.
+	Exclusive int `json:"exclusive"`
+}
+
+// maxSubtrees bounds the subtrees reported per lockfile.
+const maxSubtrees = 5
+
+// lockPackage is one resolved package. Deps are keys into the graph.
+type lockPackage struct {
+	name    string
+	version string
+	deps    []string
+}
+
+// lockGraph is a lockfile's resolved packages keyed by a parser-specific
+// key, and the keys of the project's direct dependencies among them. The
+// project's own packages are not part of it.
+type lockGraph struct {
+	packages map[string]*lockPackage
+	roots    []string
+	graph    bool
+}
+
+func newLockGraph(graph bool) *lockGraph {
+	return &lockGraph{packages: map[string]*lockPackage{}, graph: graph}
+}
+
+// lockfileParser reads a lockfile. direct holds the direct dependencies
+// parsed from the manifests next to it, for lockfiles that do not record
+// the project's own requirements.
+type lockfileParser struct {
+	ecosystem string
+	parse     func(content string, direct []Dependency) *lockGraph
+}
+
+var lockfileParsers = map[string]lockfileParser{
+	"go.sum":            {EcosystemGo, parseGoSum},
+	"package-lock.json": {EcosystemNPM, parsePackageLock},
+	"pnpm-lock.yaml":    {EcosystemNPM, parsePnpmLock},
+	"yarn.lock":         {EcosystemNPM, parseYarnLock},
+	"Cargo.lock":        {EcosystemCargo, parseCargoLock},
+	"poetry.lock":       {EcosystemPyPI, parsePoetryLock},
+	"uv.lock":           {EcosystemPyPI, parseUvLock},
+}
+
+// scanLockfiles summarizes every lockfile under root. deps are the
+// dependencies scanManifests found there.
+func scanLockfiles(root string, deps []Dependency) []LockfileSummary {
+	var summaries []LockfileSummary
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parser, ok := lockfileParsers[d.Name()]
+		if !ok {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+
+		var direct []Dependency
+		for _, dep := range deps {
+			if dep.Direct && dep.Ecosystem == parser.ecosystem && path.Dir(dep.Manifest) == path.Dir(rel) {
+				direct = append(direct, dep)
+			}
+		}
+		if g := parser.parse(string(data), direct); g != nil && len(g.packages) > 0 {
+			s := g.summarize()
+			s.Ecosystem, s.Lockfile = parser.ecosystem, rel
+			summaries = append(summaries, s)
+		}
+		return nil
+	})
+	return summaries
+}
+
+// lockfilesGuidance reports the transitive weight the lockfiles show and
+// names the heaviest direct dependency in each.
+func lockfilesGuidance(locks []LockfileSummary) string {
+	var clauses []string
+	for _, l := range locks {
+		if path.Base(l.Lockfile) == "go.sum" {
+			// go.sum also hashes test-only and pruned-out modules, so it
+			// does not say what gets built.
+			clauses = append(clauses, fmt.Sprintf("%d direct and %d other modules with source hashes in %s", l.Direct, l.Transitive, l.Lockfile))
+		} else {
+			clauses = append(clauses, fmt.Sprintf("%d direct and %d transitive packages in %s", l.Direct, l.Transitive, l.Lockfile))
+		}
+		if len(l.Subtrees) > 0 {
+			heaviest := l.Subtrees[0]
+			clauses = append(clauses, fmt.Sprintf("%s pulls in the most (%d, %d of them through nothing else)", heaviest.Name, heaviest.Transitive, heaviest.Exclusive))
+		}
+	}
+	return " TRANSITIVE DEPENDENCIES: the lockfiles list " + strings.Join(clauses, "; ") + "." +
+		" Tell the user how much each direct dependency really brings in; " +
+		"a small feature that pulls in a large subtree is a candidate for replacement."
+}
+
+// summarize counts the graph's packages and, when it has edges, weighs the
+// subtree under each direct dependency.
+func (g *lockGraph) summarize() LockfileSummary {
+	roots := map[string]bool{}
+	for _, r := range g.roots {
+		if g.packages[r] != nil {
+			roots[r] = true
+		}
+	}
+	s := LockfileSummary{
+		Packages:   len(g.packages),
+		Direct:     len(roots),
+		Transitive: len(g.packages) - len(roots),
+		Graph:      g.graph,
+	}
+	if !g.graph {
+		return s
+	}
+
+	reach := map[string]map[string]bool{}
+	reachedBy := map[string]int{}
+	for r := range roots {
+		seen := map[string]bool{r: true}
+		queue := []string{r}
+		for len(queue) > 0 {
+			p := g.packages[queue[0]]
+			queue = queue[1:]
+			for _, dep := range p.deps {
+				if !seen[dep] && g.packages[dep] != nil {
+					seen[dep] = true
+					queue = append(queue, dep)
+				}
+			}
+		}
+		delete(seen, r)
+		reach[r] = seen
+		for p := range seen {
+			reachedBy[p]++
+		}
+	}
+
+	for r, seen := range reach {
+		sub := DependencySubtree{Name: g.packages[r].name, Version: g.packages[r].version, Transitive: len(seen)}
+		for p := range seen {
+			// A direct dependency stays when another one that requires it
+			// is removed.
+			if reachedBy[p] == 1 && !roots[p] {
+				sub.Exclusive++
+			}
+		}
+		if sub.Transitive > 0 {
+			s.Subtrees = append(s.Subtrees, sub)
+		}
+	}
+	sort.Slice(s.Subtrees, func(i, j int) bool {
+		a, b := s.Subtrees[i], s.Subtrees[j]
+		if a.Transitive != b.Transitive {
+			return a.Transitive > b.Transitive
+		}
+		if a.Exclusive != b.Exclusive {
+			return a.Exclusive > b.Exclusive
+		}
+		return a.Name < b.Name
+	})
+	if len(s.Subtrees) > maxSubtrees {
+		s.Subtrees = s.Subtrees[:maxSubtrees]
+	}
+	return s
+}
+
+// parseGoSum counts the modules whose source go.sum has a hash for. Modules
+// listed only by their go.mod hash were consulted for version selection
+// but are not built. go.sum has no graph, so only counts come out.
+func parseGoSum(content string, direct []Dependency) *lockGraph {
+	g := newLockGraph(false)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		g.packages[fields[0]] = &lockPackage{name: fields[0], version: fields[1]}
+	}
+	for _, d := range direct {
+		g.roots = append(g.roots, d.Name)
+	}
+	return g
+}
+
+type npmLockEntry struct {
+	Version              string            `json:"version"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	Requires             map[string]string `json:"requires"`
+}
+
+// parsePackageLock reads the flat "packages" map of lockfileVersion 2 and
+// 3, or the nested "dependencies" tree of version 1, keying packages by
+// their node_modules path.
+func parsePackageLock(content string, direct []Dependency) *lockGraph {
+	var lock struct {
+		Packages     map[string]npmLockEntry    `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+
+	entries := map[string]npmLockEntry{}
+	for key, e := range lock.Packages {
+		if strings.Contains(key, "node_modules/") && !e.Link {
+			entries[key] = e
+		}
+	}
+	if len(lock.Packages) == 0 {
+		flattenPackageLockV1("", lock.Dependencies, entries)
+	}
+
+	g := newLockGraph(true)
+	for key, e := range entries {
+		p := &lockPackage{name: npmLockName(key), version: e.Version}
+		for _, deps := range []map[string]string{e.Dependencies, e.OptionalDependencies, e.PeerDependencies, e.Requires} {
+			for name := range deps {
+				if dep, ok := resolveNodeModule(entries, key, name); ok {
+					p.deps = append(p.deps, dep)
+				}
+			}
+		}
+		g.packages[key] = p
+	}
+
+	rootDeps := map[string]bool{}
+	if root, ok := lock.Packages[""]; ok {
+		for _, deps := range []map[string]string{root.Dependencies, root.DevDependencies, root.OptionalDependencies, root.PeerDependencies} {
+			for name := range deps {
+				rootDeps[name] = true
+			}
+		}
+	} else {
+		for _, d := range direct {
+			rootDeps[d.Name] = true
+		}
+	}
+	for name := range rootDeps {
+		if key, ok := resolveNodeModule(entries, "", name); ok {
+			g.roots = append(g.roots, key)
+		}
+	}
+	return g
+}
+
+// flattenPackageLockV1 converts a version 1 dependency tree into the
+// node_modules paths later versions use.
+func flattenPackageLockV1(prefix string, deps map[string]json.RawMessage, entries map[string]npmLockEntry) {
+	for name, raw := range deps {
+		var e struct {
+			npmLockEntry
+			Dependencies map[string]json.RawMessage `json:"dependencies"`
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			continue
+		}
+		key := prefix + "node_modules/" + name
+		entries[key] = e.npmLockEntry
+		flattenPackageLockV1(key+"/", e.Dependencies, entries)
+	}
+}
+
+// npmLockName returns the package name at the end of a node_modules path.
+func npmLockName(key string) string {
+	return key[strings.LastIndex(key, "node_modules/")+len("node_modules/"):]
+}
+
+// resolveNodeModule finds the package that name resolves to from the
+// package at key, the way Node does: its own node_modules first, then each
+// enclosing one up to the root.
+func resolveNodeModule(entries map[string]npmLockEntry, key, name string) (string, bool) {
+	for {
+		candidate := "node_modules/" + name
+		if key != "" {
+			candidate = key + "/" + candidate
+		}
+		if _, ok := entries[candidate]; ok {
+			return candidate, true
+		}
+		if key == "" {
+			return "", false
+		}
+		i := strings.LastIndex(key, "/node_modules/")
+		if i < 0 {
+			key = ""
+		} else {
+			key = key[:i]
+		}
+	}
+}
+
+type pnpmDeps struct {
+	Dependencies         map[string]any `yaml:"dependencies"`
+	DevDependencies      map[string]any `yaml:"devDependencies"`
+	OptionalDependencies map[string]any `yaml:"optionalDependencies"`
+}
+
+// all yields each dependency's name and resolved version.
+func (d pnpmDeps) all(yield func(name, version string)) {
+	for _, deps := range []map[string]any{d.Dependencies, d.DevDependencies, d.OptionalDependencies} {
+		for name, v := range deps {
+			// Lockfile version 6 and later map importer dependencies to a
+			// specifier and version; earlier ones to the version alone.
+			switch v := v.(type) {
+			case map[any]any:
+				if version, ok := v["version"]; ok {
+					yield(name, fmt.Sprint(version))
+				}
+			case nil:
+			default:
+				yield(name, fmt.Sprint(v))
+			}
+		}
+	}
+}
+
+// parsePnpmLock reads pnpm-lock.yaml. Packages are keyed name@version in
+// lockfile version 6 and later (with the dependency graph under snapshots
+// since version 9) and /name/version before that.
+func parsePnpmLock(content string, direct []Dependency) *lockGraph {
+	var lock struct {
+		pnpmDeps  `yaml:",inline"`
+		Importers map[string]pnpmDeps `yaml:"importers"`
+		Packages  map[string]pnpmDeps `yaml:"packages"`
+		Snapshots map[string]pnpmDeps `yaml:"snapshots"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+	nodes := lock.Snapshots
+	if len(nodes) == 0 {
+		nodes = lock.Packages
+	}
+
+	g := newLockGraph(true)
+	resolve := func(name, version string) (string, bool) {
+		if strings.HasPrefix(version, "link:") || strings.HasPrefix(version, "file:") {
+			return "", false
+		}
+		// Aliased dependencies carry the full key of their target.
+		for _, key := range []string{version, name + "@" + version, name + "/" + version} {
+			key = strings.TrimPrefix(key, "/")
+			if _, ok := nodes[key]; ok {
+				return key, true
+			}
+			if _, ok := nodes["/"+key]; ok {
+				return "/" + key, true
+			}
+		}
+		return "", false
+	}
+	for key, deps := range nodes {
+		name, version := pnpmKeyName(key)
+		p := &lockPackage{name: name, version: version}
+		deps.all(func(name, version string) {
+			if dep, ok := resolve(name, version); ok {
+				p.deps = append(p.deps, dep)
+			}
+		})
+		g.packages[key] = p
+	}
+
+	importers := lock.Importers
+	if len(importers) == 0 {
+		importers = map[string]pnpmDeps{".": lock.pnpmDeps}
+	}
+	for _, imp := range importers {
+		imp.all(func(name, version string) {
+			if key, ok := resolve(name, version); ok {
+				g.roots = append(g.roots, key)
+			}
+		})
+	}
+	return g
+}
+
+// pnpmKeyName splits a pnpm package key such as /@scope/pkg@1.0.0(peer@2)
+// or /pkg/1.0.0 into name and version.
+func pnpmKeyName(key string) (name, version string) {
+	base := strings.TrimPrefix(key, "/")
+	if i := strings.Index(base, "("); i > 0 {
+		base = base[:i]
+	}
+	if i := strings.LastIndex(base, "@"); i > 0 {
+		return base[:i], base[i+1:]
+	}
+	if i := strings.LastIndex(base, "/"); i > 0 {
+		return base[:i], base[i+1:]
+	}
+	return base, ""
+}
+
+// parseYarnLock reads both the classic yarn.lock format and the YAML-like
+// format of Yarn 2 and later. Each entry lists the specifiers that resolve
+// to it, e.g. "lodash@^4.17.0, lodash@^4.17.21:", and its dependencies'
+// names and ranges, which name other entries' specifiers.
+func parseYarnLock(content string, direct []Dependency) *lockGraph {
+	type entry struct {
+		key  string
+		deps [][2]string
+	}
+	var entries []*entry
+	bySpec := map[string]string{}
+	g := newLockGraph(true)
+
+	var cur *entry
+	inDeps := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			cur, inDeps = nil, false
+			header := strings.TrimSuffix(trimmed, ":")
+			if header == "__metadata" || strings.Contains(header, "@workspace:") {
+				continue
+			}
+			specs := strings.Split(header, ",")
+			for i := range specs {
+				specs[i] = strings.Trim(strings.TrimSpace(specs[i]), `"`)
+			}
+			name, _ := splitYarnSpec(specs[0])
+			cur = &entry{key: specs[0]}
+			g.packages[cur.key] = &lockPackage{name: name}
+			entries = append(entries, cur)
+			for _, spec := range specs {
+				bySpec[spec] = cur.key
+			}
+		case cur == nil:
+		case indent == 2:
+			key, value := splitYarnField(trimmed)
+			inDeps = key == "dependencies" || key == "optionalDependencies"
+			if key == "version" {
+				g.packages[cur.key].version = value
+			}
+		case inDeps:
+			name, spec := splitYarnField(trimmed)
+			cur.deps = append(cur.deps, [2]string{name, spec})
+		}
+	}
+
+	resolve := func(name, spec string) (string, bool) {
+		for _, s := range []string{name + "@" + spec, name + "@npm:" + spec} {
+			if key, ok := bySpec[s]; ok {
+				return key, true
+			}
+		}
+		return "", false
+	}
+	for _, e := range entries {
+		p := g.packages[e.key]
+		for _, d := range e.deps {
+			if key, ok := resolve(d[0], d[1]); ok {
+				p.deps = append(p.deps, key)
+			}
+		}
+	}
+	for _, d := range direct {
+		if key, ok := resolve(d.Name, d.Version); ok {
+			g.roots = append(g.roots, key)
+		}
+	}
+	return g
+}
+
+// splitYarnSpec splits a specifier such as @scope/pkg@^1.0.0 into the name
+// and range.
+func splitYarnSpec(spec string) (name, rng string) {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, ""
+}
+
+// splitYarnField splits a classic `key "value"` or a Yarn 2 `key: value`
+// line, unquoting both.
+func splitYarnField(line string) (key, value string) {
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			key, value = line[1:end+1], line[end+2:]
+		}
+	} else if i := strings.IndexAny(line, " :"); i >= 0 {
+		key, value = line[:i], line[i:]
+	} else {
+		key = line
+	}
+	value = strings.TrimPrefix(strings.TrimSpace(value), ":")
+	return key, strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+// parseCargoLock reads Cargo.lock. Packages without a source are the
+// workspace's own crates; what they depend on is direct.
+func parseCargoLock(content string, direct []Dependency) *lockGraph {
+	type crate struct {
+		name, version string
+		local         bool
+		deps          []string
+	}
+	crates := map[int]*crate{}
+	for _, e := range parseTOML(content) {
+		if e.Table != "package" {
+			continue
+		}
+		if crates[e.Index] == nil {
+			crates[e.Index] = &crate{local: true}
+		}
+		c := crates[e.Index]
+		switch e.Key {
+		case "name":
+			c.name = tomlString(e.Value)
+		case "version":
+			c.version = tomlString(e.Value)
+		case "source":
+			c.local = false
+		case "dependencies":
+			c.deps = tomlStrings(e.Value)
+		}
+	}
+
+	// Dependencies are written "name" when only one version is locked and
+	// "name version" or "name version (source)" otherwise.
+	versions := map[string][]string{}
+	for _, c := range crates {
+		versions[c.name] = append(versions[c.name], c.version)
+	}
+	resolve := func(dep string) string {
+		fields := strings.Fields(dep)
+		if len(fields) >= 2 {
+			return fields[0] + "@" + fields[1]
+		}
+		if len(fields) == 1 && len(versions[fields[0]]) == 1 {
+			return fields[0] + "@" + versions[fields[0]][0]
+		}
+		return ""
+	}
+
+	g := newLockGraph(true)
+	local := map[string]bool{}
+	for _, c := range crates {
+		if c.local {
+			local[c.name+"@"+c.version] = true
+		}
+	}
+	for _, c := range crates {
+		key := c.name + "@" + c.version
+		var deps []string
+		for _, d := range c.deps {
+			if dep := resolve(d); dep != "" && !local[dep] {
+				deps = append(deps, dep)
+			}
+		}
+		if c.local {
+			g.roots = append(g.roots, deps...)
+			continue
+		}
+		g.packages[key] = &lockPackage{name: c.name, version: c.version, deps: deps}
+	}
+	return g
+}
+
+// parsePoetryLock reads poetry.lock, keying packages by normalized name.
+// The project itself is not listed, so direct dependencies come from
+// pyproject.toml.
+func parsePoetryLock(content string, direct []Dependency) *lockGraph {
+	g := newLockGraph(true)
+	var cur *lockPackage
+	for _, e := range parseTOML(content) {
+		switch {
+		case e.Table == "package" && e.Key == "name":
+			cur = &lockPackage{name: tomlString(e.Value)}
+			g.packages[normalizePackage(EcosystemPyPI, cur.name)] = cur
+		case cur == nil:
+		case e.Table == "package" && e.Key == "version":
+			cur.version = tomlString(e.Value)
+		case e.Table == "package.dependencies":
+			cur.deps = append(cur.deps, normalizePackage(EcosystemPyPI, e.Key))
+		}
+	}
+	for _, d := range direct {
+		g.roots = append(g.roots, normalizePackage(EcosystemPyPI, d.Name))
+	}
+	return g
+}
+
+// parseUvLock reads uv.lock. The project and its workspace members are
+// listed with an editable or virtual source; what they depend on, including
+// optional and development groups, is direct.
+func parseUvLock(content string, direct []Dependency) *lockGraph {
+	g := newLockGraph(true)
+	local := map[*lockPackage]bool{}
+	var cur *lockPackage
+	names := func(value string) []string {
+		var out []string
+		for _, item := range tomlStrings(value) {
+			if name := tomlString(tomlInline(item)["name"]); name != "" {
+				out = append(out, normalizePackage(EcosystemPyPI, name))
+			}
+		}
+		return out
+	}
+	for _, e := range parseTOML(content) {
+		switch {
+		case e.Table == "package" && e.Key == "name":
+			cur = &lockPackage{name: tomlString(e.Value)}
+			g.packages[normalizePackage(EcosystemPyPI, cur.name)] = cur
+		case cur == nil:
+		case e.Table == "package" && e.Key == "version":
+			cur.version = tomlString(e.Value)
+		case e.Table == "package" && e.Key == "source":
+			source := tomlInline(e.Value)
+			_, editable := source["editable"]
+			_, virtual := source["virtual"]
+			local[cur] = editable || virtual
+		case e.Table == "package" && e.Key == "dependencies",
+			e.Table == "package.optional-dependencies",
+			e.Table == "package.dev-dependencies":
+			cur.deps = append(cur.deps, names(e.Value)...)
+		}
+	}
+	for key, p := range g.packages {
+		if local[p] {
+			g.roots = append(g.roots, p.deps...)
+			delete(g.packages, key)
+		}
+	}
+	return g
+}
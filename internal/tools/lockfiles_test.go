@@ -0,0 +1,455 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Every fixture locks the same graph: direct dependencies a and d, with
+// a → b → c and d → c, e. Where the format allows it, d needs a second
+// version of c, so each direct dependency's subtree is its own.
+var (
+	sharedC = []DependencySubtree{
+		{Name: "a", Version: "1.0.0", Transitive: 2, Exclusive: 1},
+		{Name: "d", Version: "1.0.0", Transitive: 2, Exclusive: 1},
+	}
+	separateC = []DependencySubtree{
+		{Name: "a", Version: "1.0.0", Transitive: 2, Exclusive: 2},
+		{Name: "d", Version: "1.0.0", Transitive: 2, Exclusive: 2},
+	}
+)
+
+const (
+	lockPackageJSON = `{"dependencies": {"a": "^1.0.0"}, "devDependencies": {"d": "^1.0.0"}}`
+	lockPyproject   = "[tool.poetry.dependencies]\npython = \"^3.11\"\nA = \"^1.0\"\n\n[tool.poetry.group.dev.dependencies]\nd = \"^1.0\"\n"
+)
+
+func TestScanLockfiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		packages int
+		subtrees []DependencySubtree
+	}{
+		{
+			name: "package-lock v3",
+			files: map[string]string{
+				"package.json": lockPackageJSON,
+				"package-lock.json": `{"lockfileVersion": 3, "packages": {
+					"": {"dependencies": {"a": "^1.0.0"}, "devDependencies": {"d": "^1.0.0"}},
+					"node_modules/a": {"version": "1.0.0", "dependencies": {"b": "^1.0.0"}},
+					"node_modules/b": {"version": "1.0.0", "dependencies": {"c": "^1.0.0"}},
+					"node_modules/c": {"version": "1.0.0"},
+					"node_modules/d": {"version": "1.0.0", "dependencies": {"c": "^2.0.0", "e": "^1.0.0"}},
+					"node_modules/d/node_modules/c": {"version": "2.0.0"},
+					"node_modules/e": {"version": "1.0.0"}}}`,
+			},
+			packages: 6,
+			subtrees: separateC,
+		},
+		{
+			name: "package-lock v1",
+			files: map[string]string{
+				"package.json": lockPackageJSON,
+				"package-lock.json": `{"lockfileVersion": 1, "dependencies": {
+					"a": {"version": "1.0.0", "requires": {"b": "^1.0.0"}},
+					"b": {"version": "1.0.0", "requires": {"c": "^1.0.0"}},
+					"c": {"version": "1.0.0"},
+					"d": {"version": "1.0.0", "requires": {"c": "^1.0.0", "e": "^1.0.0"}},
+					"e": {"version": "1.0.0"}}}`,
+			},
+			packages: 5,
+			subtrees: sharedC,
+		},
+		{
+			name: "pnpm v9",
+			files: map[string]string{
+				"package.json": lockPackageJSON,
+				"pnpm-lock.yaml": `lockfileVersion: '9.0'
+importers:
+  .:
+    dependencies:
+      a:
+        specifier: ^1.0.0
+        version: 1.0.0
+    devDependencies:
+      d:
+        specifier: ^1.0.0
+        version: 1.0.0(a@1.0.0)
+packages:
+  a@1.0.0:
+    resolution: {integrity: sha512-a}
+snapshots:
+  a@1.0.0:
+    dependencies:
+      b: 1.0.0
+  b@1.0.0:
+    dependencies:
+      c: 1.0.0
+  c@1.0.0: {}
+  c@2.0.0: {}
+  d@1.0.0(a@1.0.0):
+    dependencies:
+      c: 2.0.0
+      e: 1.0.0
+  e@1.0.0: {}
+`,
+			},
+			packages: 6,
+			subtrees: separateC,
+		},
+		{
+			name: "pnpm v5",
+			files: map[string]string{
+				"package.json": lockPackageJSON,
+				"pnpm-lock.yaml": `lockfileVersion: 5.4
+specifiers:
+  a: ^1.0.0
+  d: ^1.0.0
+dependencies:
+  a: 1.0.0
+devDependencies:
+  d: 1.0.0
+packages:
+  /a/1.0.0:
+    dependencies:
+      b: 1.0.0
+  /b/1.0.0:
+    dependencies:
+      c: 1.0.0
+  /c/1.0.0:
+    resolution: {integrity: sha512-c}
+  /d/1.0.0:
+    dependencies:
+      c: 1.0.0
+      e: 1.0.0
+  /e/1.0.0:
+    resolution: {integrity: sha512-e}
+`,
+			},
+			packages: 5,
+			subtrees: sharedC,
+		},
+		{
+			name: "yarn classic",
+			files: map[string]string{
+				"package.json": lockPackageJSON,
+				"yarn.lock": `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+a@^1.0.0:
+  version "1.0.0"
+  resolved "https://registry.yarnpkg.com/a/-/a-1.0.0.tgz"
+  dependencies:
+    b "^1.0.0"
+
+b@^1.0.0:
+  version "1.0.0"
+  dependencies:
+    c "^1.0.0"
+
+"c@^1.0.0", c@^1.1.0:
+  version "1.1.0"
+
+d@^1.0.0:
+  version "1.0.0"
+  dependencies:
+    c "^1.1.0"
+    e "^1.0.0"
+
+e@^1.0.0:
+  version "1.0.0"
+`,
+			},
+			packages: 5,
+			subtrees: sharedC,
+		},
+		{
+			name: "yarn berry",
+			files: map[string]string{
+				"package.json": lockPackageJSON,
+				"yarn.lock": `__metadata:
+  version: 6
+  cacheKey: 8
+
+"a@npm:^1.0.0":
+  version: 1.0.0
+  resolution: "a@npm:1.0.0"
+  dependencies:
+    b: "npm:^1.0.0"
+  languageName: node
+  linkType: hard
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    a: "npm:^1.0.0"
+    d: "npm:^1.0.0"
+
+"b@npm:^1.0.0":
+  version: 1.0.0
+  dependencies:
+    c: "npm:^1.0.0"
+
+"c@npm:^1.0.0":
+  version: 1.0.0
+
+"c@npm:^2.0.0":
+  version: 2.0.0
+
+"d@npm:^1.0.0":
+  version: 1.0.0
+  dependencies:
+    c: "npm:^2.0.0"
+    e: "npm:^1.0.0"
+
+"e@npm:^1.0.0":
+  version: 1.0.0
+`,
+			},
+			packages: 6,
+			subtrees: separateC,
+		},
+		{
+			name: "Cargo.lock",
+			files: map[string]string{
+				"Cargo.lock": `version = 3
+
+[[package]]
+name = "a"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = [
+ "b",
+]
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "a",
+ "d",
+]
+
+[[package]]
+name = "b"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = [
+ "c 1.0.0",
+]
+
+[[package]]
+name = "c"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "c"
+version = "2.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "d"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = [
+ "c 2.0.0 (registry+https://github.com/rust-lang/crates.io-index)",
+ "e",
+]
+
+[[package]]
+name = "e"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`,
+			},
+			packages: 6,
+			subtrees: separateC,
+		},
+		{
+			name: "poetry.lock",
+			files: map[string]string{
+				"pyproject.toml": lockPyproject,
+				"poetry.lock": `[[package]]
+name = "a"
+version = "1.0.0"
+optional = false
+files = [
+    {file = "a-1.0.0.tar.gz", hash = "sha256:a"},
+]
+
+[package.dependencies]
+B = ">=1.0"
+
+[package.extras]
+test = ["pytest"]
+
+[[package]]
+name = "b"
+version = "1.0.0"
+
+[package.dependencies]
+c = "*"
+
+[[package]]
+name = "c"
+version = "1.0.0"
+
+[[package]]
+name = "d"
+version = "1.0.0"
+
+[package.dependencies]
+c = "*"
+e = {version = ">=1.0", markers = "python_version < \"3.12\""}
+
+[[package]]
+name = "e"
+version = "1.0.0"
+
+[metadata]
+lock-version = "2.0"
+`,
+			},
+			packages: 5,
+			subtrees: []DependencySubtree{
+				{Name: "a", Version: "1.0.0", Transitive: 2, Exclusive: 1},
+				{Name: "d", Version: "1.0.0", Transitive: 2, Exclusive: 1},
+			},
+		},
+		{
+			name: "uv.lock",
+			files: map[string]string{
+				"uv.lock": `version = 1
+requires-python = ">=3.11"
+
+[[package]]
+name = "a"
+version = "1.0.0"
+source = { registry = "https://pypi.org/simple" }
+dependencies = [
+    { name = "b" },
+]
+
+[[package]]
+name = "app"
+version = "0.1.0"
+source = { editable = "." }
+dependencies = [
+    { name = "a" },
+]
+
+[package.dev-dependencies]
+dev = [
+    { name = "d" },
+]
+
+[[package]]
+name = "b"
+version = "1.0.0"
+source = { registry = "https://pypi.org/simple" }
+dependencies = [
+    { name = "c" },
+]
+
+[[package]]
+name = "c"
+version = "1.0.0"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "d"
+version = "1.0.0"
+source = { registry = "https://pypi.org/simple" }
+dependencies = [
+    { name = "c" },
+    { name = "e", marker = "sys_platform == 'win32'" },
+]
+
+[[package]]
+name = "e"
+version = "1.0.0"
+source = { registry = "https://pypi.org/simple" }
+`,
+			},
+			packages: 5,
+			subtrees: sharedC,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, c.files)
+			deps, _, err := scanManifests(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			locks := scanLockfiles(dir, deps)
+			if len(locks) != 1 {
+				t.Fatalf("expected one lockfile, got %+v", locks)
+			}
+			l := locks[0]
+			if l.Packages != c.packages || l.Direct != 2 || l.Transitive != c.packages-2 || !l.Graph {
+				t.Errorf("unexpected counts: %+v", l)
+			}
+			if !reflect.DeepEqual(l.Subtrees, c.subtrees) {
+				t.Errorf("subtrees = %+v, want %+v", l.Subtrees, c.subtrees)
+			}
+		})
+	}
+}
+
+func TestScanLockfiles_GoSum(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.0.0 // indirect\n)\n",
+		"go.sum": "example.com/a v1.0.0 h1:a=\nexample.com/a v1.0.0/go.mod h1:a=\n" +
+			"example.com/b v1.0.0 h1:b=\nexample.com/b v1.0.0/go.mod h1:b=\n" +
+			"example.com/old v0.1.0/go.mod h1:old=\n",
+		"vendor/example.com/x/go.sum": "example.com/ignored v1.0.0 h1:x=\n",
+	})
+	deps, _, _ := scanManifests(dir)
+
+	want := []LockfileSummary{{Ecosystem: EcosystemGo, Lockfile: "go.sum", Packages: 2, Direct: 1, Transitive: 1}}
+	if got := scanLockfiles(dir, deps); !reflect.DeepEqual(got, want) {
+		t.Errorf("scanLockfiles() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandleDeps_Lockfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": lockPackageJSON,
+		"package-lock.json": `{"lockfileVersion": 3, "packages": {
+			"": {"dependencies": {"a": "^1.0.0"}},
+			"node_modules/a": {"version": "1.0.0", "dependencies": {"b": "^1.0.0"}},
+			"node_modules/b": {"version": "1.0.0"}}}`,
+	})
+
+	_, output, err := HandleDeps(context.Background(), &mcp.CallToolRequest{}, DepsInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Lockfiles) != 1 || output.Lockfiles[0].Transitive != 1 {
+		t.Fatalf("unexpected lockfiles: %+v", output.Lockfiles)
+	}
+	if !strings.Contains(output.Guidance, "a pulls in the most (1, 1 of them through nothing else). Tell the user") {
+		t.Errorf("expected the heaviest dependency to end the lockfile sentence, got %q", output.Guidance)
+	}
+}
+
+func TestLockfilesGuidance_GoSum(t *testing.T) {
+	got := lockfilesGuidance([]LockfileSummary{{Ecosystem: EcosystemGo, Lockfile: "go.sum", Direct: 4, Transitive: 28}})
+	if !strings.Contains(got, "4 direct and 28 other modules with source hashes in go.sum. Tell") {
+		t.Errorf("unexpected guidance %q", got)
+	}
+}
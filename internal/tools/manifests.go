@@ -0,0 +1,495 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dependency ecosystems reported by the manifest parsers.
+const (
+	EcosystemGo    = "go"
+	EcosystemNPM   = "npm"
+	EcosystemPyPI  = "pypi"
+	EcosystemCargo = "cargo"
+)
+
+type Dependency struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	Direct    bool   `json:"direct"`
+	// Kind distinguishes non-runtime dependencies, e.g. "dev", "build",
+	// "peer", "optional", or a named dependency group.
+	Kind string `json:"kind,omitempty"`
+	// Replace is the replacement target from a go.mod replace directive.
+	Replace  string `json:"replace,omitempty"`
+	Manifest string `json:"manifest"`
+	Line     int    `json:"line"`
+	// Capability is the taxonomy ID of what the package provides, e.g.
+	// "logging", when mtb knows it.
+	Capability string `json:"capability,omitempty"`
+}
+
+// manifestParsers maps manifest file names to in-process parsers. Each
+// parser receives the manifest path relative to the scan root.
+var manifestParsers = map[string]func(rel, content string) []Dependency{
+	"go.mod":         parseGoMod,
+	"package.json":   parsePackageJSON,
+	"pyproject.toml": parsePyproject,
+	"Cargo.toml":     parseCargoToml,
+}
+
+// unsupportedManifests are recognized but not parsed; finding one keeps the
+// agent guidance in play for that ecosystem.
+var unsupportedManifests = map[string]bool{
+	"Pipfile":                  true,
+	"setup.py":                 true,
+	"pom.xml":                  true,
+	"build.gradle":             true,
+	"build.gradle.kts":         true,
+	"Gemfile":                  true,
+	"composer.json":            true,
+	"packages.config":          true,
+	"Package.swift":            true,
+	"mix.exs":                  true,
+	"deps.edn":                 true,
+	"project.clj":              true,
+	"pubspec.yaml":             true,
+	"environment.yml":          true,
+	"conanfile.txt":            true,
+	"vcpkg.json":               true,
+	"Directory.Packages.props": true,
+}
+
+// skipDirs are never descended into when looking for manifests: they hold
+// other projects' manifests or build output.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".mtb":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+func isRequirementsFile(name string) bool {
+	return strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt")
+}
+
+// scanManifests walks root and parses every supported manifest. It returns
+// the dependencies found plus the relative paths of manifests it recognized
+// but could not parse.
+func scanManifests(root string) ([]Dependency, []string, error) {
+	var deps []Dependency
+	var unsupported []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && skipDirs[name] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = name
+		}
+
+		parse := manifestParsers[name]
+		if parse == nil && isRequirementsFile(name) {
+			parse = parseRequirements
+		}
+		if parse == nil {
+			if unsupportedManifests[name] || strings.HasSuffix(name, ".csproj") {
+				unsupported = append(unsupported, rel)
+			}
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		deps = append(deps, parse(rel, string(data))...)
+		return nil
+	})
+
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].Manifest != deps[j].Manifest {
+			return deps[i].Manifest < deps[j].Manifest
+		}
+		return deps[i].Line < deps[j].Line
+	})
+	return deps, unsupported, err
+}
+
+// parseGoMod reads require and replace directives, in both single-line and
+// block form. Requires marked "// indirect" are reported as indirect.
+func parseGoMod(rel, content string) []Dependency {
+	var deps []Dependency
+	type replacement struct{ version, target string }
+	replaces := map[string][]replacement{}
+
+	block := ""
+	for i, raw := range strings.Split(content, "\n") {
+		code, comment, _ := strings.Cut(strings.TrimSpace(raw), "//")
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+
+		verb := block
+		if block == "" {
+			var rest string
+			verb, rest, _ = strings.Cut(code, " ")
+			code = strings.TrimSpace(rest)
+			if code == "(" {
+				block = verb
+				continue
+			}
+		} else if code == ")" {
+			block = ""
+			continue
+		}
+
+		switch verb {
+		case "require":
+			if dep, ok := goRequire(code, comment, rel, i+1); ok {
+				deps = append(deps, dep)
+			}
+		case "replace":
+			if from, version, target, ok := goReplace(code); ok {
+				replaces[from] = append(replaces[from], replacement{version, target})
+			}
+		}
+	}
+
+	for i := range deps {
+		for _, r := range replaces[deps[i].Name] {
+			if r.version == "" || r.version == deps[i].Version {
+				deps[i].Replace = r.target
+			}
+		}
+	}
+	return deps
+}
+
+func goRequire(code, comment, rel string, line int) (Dependency, bool) {
+	fields := strings.Fields(code)
+	if len(fields) < 2 {
+		return Dependency{}, false
+	}
+	return Dependency{
+		Ecosystem: EcosystemGo,
+		Name:      strings.Trim(fields[0], `"`),
+		Version:   fields[1],
+		Direct:    strings.TrimSpace(comment) != "indirect",
+		Manifest:  rel,
+		Line:      line,
+	}, true
+}
+
+// goReplace parses "old [version] => new [version]".
+func goReplace(code string) (from, version, target string, ok bool) {
+	left, right, found := strings.Cut(code, "=>")
+	if !found {
+		return "", "", "", false
+	}
+	l := strings.Fields(left)
+	if len(l) == 0 {
+		return "", "", "", false
+	}
+	from = strings.Trim(l[0], `"`)
+	if len(l) > 1 {
+		version = l[1]
+	}
+	return from, version, strings.Join(strings.Fields(right), " "), true
+}
+
+// packageJSONSections maps package.json dependency sections to kinds.
+var packageJSONSections = []struct{ key, kind string }{
+	{"dependencies", ""},
+	{"devDependencies", "dev"},
+	{"peerDependencies", "peer"},
+	{"optionalDependencies", "optional"},
+}
+
+func parsePackageJSON(rel, content string) []Dependency {
+	var manifest map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil
+	}
+
+	var deps []Dependency
+	for _, section := range packageJSONSections {
+		raw, ok := manifest[section.key]
+		if !ok {
+			continue
+		}
+		var entries map[string]string
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			continue
+		}
+		sectionStart := jsonKeyOffset(content, 0, section.key)
+		for name, version := range entries {
+			deps = append(deps, Dependency{
+				Ecosystem: EcosystemNPM,
+				Name:      name,
+				Version:   version,
+				Direct:    true,
+				Kind:      section.kind,
+				Manifest:  rel,
+				Line:      lineAt(content, jsonKeyOffset(content, sectionStart, name)),
+			})
+		}
+	}
+	return deps
+}
+
+// jsonKeyOffset returns the offset of "key": at or after from, or from if
+// it cannot be found. JSON decoding drops positions, so line numbers are
+// recovered by searching the source text.
+func jsonKeyOffset(content string, from int, key string) int {
+	quoted := `"` + key + `"`
+	for i := from; ; {
+		j := strings.Index(content[i:], quoted)
+		if j < 0 {
+			return from
+		}
+		i += j
+		rest := strings.TrimLeft(content[i+len(quoted):], " \t\r\n")
+		if strings.HasPrefix(rest, ":") {
+			return i
+		}
+		i++
+	}
+}
+
+// lineAt returns the 1-based line number of offset in content.
+func lineAt(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// pep508 matches the name and version specifier of a PEP 508 requirement,
+// e.g. "requests[security]>=2.31 ; python_version >= '3.8'".
+var pep508 = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(\([^)]*\)|[<>=!~][^;]*)?`)
+
+func parseRequirement(spec string) (name, version string, ok bool) {
+	m := pep508.FindStringSubmatch(strings.TrimSpace(spec))
+	if m == nil {
+		return "", "", false
+	}
+	version = strings.Trim(strings.TrimSpace(m[3]), "()")
+	return m[1], strings.ReplaceAll(version, " ", ""), true
+}
+
+func parseRequirements(rel, content string) []Dependency {
+	kind := ""
+	base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(rel), "requirements"), ".txt")
+	if base = strings.Trim(base, "-_."); base != "" {
+		kind = base
+	}
+
+	var deps []Dependency
+	for i, raw := range strings.Split(content, "\n") {
+		line, _, _ := strings.Cut(raw, " #")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		name, version, ok := parseRequirement(line)
+		if !ok {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Ecosystem: EcosystemPyPI,
+			Name:      name,
+			Version:   version,
+			Direct:    true,
+			Kind:      kind,
+			Manifest:  rel,
+			Line:      i + 1,
+		})
+	}
+	return deps
+}
+
+// parsePyproject reads PEP 621 ([project] and optional dependencies),
+// PEP 735 dependency groups, and Poetry dependency tables.
+func parsePyproject(rel, content string) []Dependency {
+	var deps []Dependency
+	addSpecs := func(e tomlEntry, kind string) {
+		// Each array item sits on its own line in most files; locate it so
+		// the reported line points at the requirement itself.
+		lines := strings.Split(content, "\n")
+		for _, spec := range tomlStrings(e.Value) {
+			name, version, ok := parseRequirement(spec)
+			if !ok {
+				continue
+			}
+			line := e.Line
+			for j := e.Line - 1; j < len(lines); j++ {
+				if strings.Contains(lines[j], `"`+spec+`"`) || strings.Contains(lines[j], `'`+spec+`'`) {
+					line = j + 1
+					break
+				}
+			}
+			deps = append(deps, Dependency{
+				Ecosystem: EcosystemPyPI,
+				Name:      name,
+				Version:   version,
+				Direct:    true,
+				Kind:      kind,
+				Manifest:  rel,
+				Line:      line,
+			})
+		}
+	}
+
+	for _, e := range parseTOML(content) {
+		switch {
+		case e.Table == "project" && e.Key == "dependencies":
+			addSpecs(e, "")
+		case e.Table == "project.optional-dependencies":
+			addSpecs(e, e.Key)
+		case e.Table == "dependency-groups":
+			addSpecs(e, e.Key)
+		case e.Table == "tool.poetry.dependencies" || e.Table == "tool.poetry.dev-dependencies" ||
+			(strings.HasPrefix(e.Table, "tool.poetry.group.") && strings.HasSuffix(e.Table, ".dependencies")):
+			if e.Key == "python" {
+				continue
+			}
+			kind := ""
+			if e.Table == "tool.poetry.dev-dependencies" {
+				kind = "dev"
+			} else if group, ok := strings.CutPrefix(e.Table, "tool.poetry.group."); ok {
+				kind = strings.TrimSuffix(group, ".dependencies")
+			}
+			deps = append(deps, Dependency{
+				Ecosystem: EcosystemPyPI,
+				Name:      e.Key,
+				Version:   tomlVersion(e.Value),
+				Direct:    true,
+				Kind:      kind,
+				Manifest:  rel,
+				Line:      e.Line,
+			})
+		}
+	}
+	return deps
+}
+
+// tomlVersion extracts a version from either a plain string value or an
+// inline table with a version key. Path, git, and workspace dependencies
+// report their source instead.
+func tomlVersion(value string) string {
+	inline := tomlInline(value)
+	if inline == nil {
+		return tomlString(value)
+	}
+	for _, key := range []string{"version", "path", "git"} {
+		if v, ok := inline[key]; ok {
+			if key == "version" {
+				return tomlString(v)
+			}
+			return key + ":" + tomlString(v)
+		}
+	}
+	if inline["workspace"] == "true" {
+		return "workspace"
+	}
+	return ""
+}
+
+// cargoKind returns the dependency kind for a Cargo.toml table, and whether
+// the table holds dependencies at all. Target-specific tables such as
+// target.'cfg(unix)'.dependencies are treated like their plain form.
+func cargoKind(table string) (string, bool) {
+	if strings.HasPrefix(table, "target.") {
+		if i := strings.LastIndex(table, "."); i > 0 {
+			table = table[i+1:]
+		}
+	}
+	switch table {
+	case "dependencies", "workspace.dependencies":
+		return "", true
+	case "dev-dependencies":
+		return "dev", true
+	case "build-dependencies":
+		return "build", true
+	}
+	return "", false
+}
+
+func parseCargoToml(rel, content string) []Dependency {
+	var deps []Dependency
+	// [dependencies.serde] style tables declare one dependency whose
+	// version appears as a key inside the table.
+	tableDeps := map[string]int{}
+
+	for _, e := range parseTOML(content) {
+		if kind, ok := cargoKind(e.Table); ok {
+			deps = append(deps, Dependency{
+				Ecosystem: EcosystemCargo,
+				Name:      e.Key,
+				Version:   tomlVersion(e.Value),
+				Direct:    true,
+				Kind:      kind,
+				Manifest:  rel,
+				Line:      e.Line,
+			})
+			continue
+		}
+
+		i := strings.LastIndex(e.Table, ".")
+		if i < 0 {
+			continue
+		}
+		kind, ok := cargoKind(e.Table[:i])
+		if !ok {
+			continue
+		}
+		idx, seen := tableDeps[e.Table]
+		if !seen {
+			idx = len(deps)
+			tableDeps[e.Table] = idx
+			deps = append(deps, Dependency{
+				Ecosystem: EcosystemCargo,
+				Name:      e.Table[i+1:],
+				Direct:    true,
+				Kind:      kind,
+				Manifest:  rel,
+				Line:      e.TableLine,
+			})
+		}
+		switch e.Key {
+		case "version":
+			deps[idx].Version = tomlString(e.Value)
+		case "path", "git":
+			if deps[idx].Version == "" {
+				deps[idx].Version = e.Key + ":" + tomlString(e.Value)
+			}
+		}
+	}
+	return deps
+}
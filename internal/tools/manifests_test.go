@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func findDep(deps []Dependency, name string) *Dependency {
+	for i := range deps {
+		if deps[i].Name == name {
+			return &deps[i]
+		}
+	}
+	return nil
+}
+
+func TestParseGoMod(t *testing.T) {
+	deps := parseGoMod("go.mod", `module example.com/app
+
+go 1.22
+
+require github.com/single/dep v1.0.0
+
+require (
+	github.com/direct/a v1.2.3
+	github.com/indirect/b v0.1.0 // indirect
+	github.com/replaced/c v2.0.0+incompatible
+)
+
+replace github.com/replaced/c => ../c
+
+replace (
+	github.com/direct/a v1.2.3 => github.com/fork/a v1.2.4
+	github.com/direct/a v9.9.9 => github.com/other/a v9.9.9
+)
+`)
+
+	if len(deps) != 4 {
+		t.Fatalf("expected 4 requires, got %d: %+v", len(deps), deps)
+	}
+
+	single := findDep(deps, "github.com/single/dep")
+	if single == nil || single.Version != "v1.0.0" || !single.Direct || single.Line != 5 {
+		t.Errorf("unexpected single-line require: %+v", single)
+	}
+	if b := findDep(deps, "github.com/indirect/b"); b == nil || b.Direct {
+		t.Errorf("expected indirect require, got %+v", b)
+	}
+	if c := findDep(deps, "github.com/replaced/c"); c == nil || c.Replace != "../c" {
+		t.Errorf("expected unversioned replace to apply, got %+v", c)
+	}
+	if a := findDep(deps, "github.com/direct/a"); a == nil || a.Replace != "github.com/fork/a v1.2.4" || a.Line != 8 {
+		t.Errorf("expected version-specific replace to apply, got %+v", a)
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	deps := parsePackageJSON("web/package.json", `{
+  "name": "web",
+  "dependencies": {
+    "axios": "^1.6.0"
+  },
+  "devDependencies": {
+    "jest": "^29.0.0",
+    "axios-mock-adapter": "^1.22.0"
+  }
+}
+`)
+
+	if len(deps) != 3 {
+		t.Fatalf("expected 3 dependencies, got %d", len(deps))
+	}
+	axios := findDep(deps, "axios")
+	if axios == nil || axios.Version != "^1.6.0" || axios.Kind != "" || axios.Line != 4 || axios.Manifest != "web/package.json" {
+		t.Errorf("unexpected axios: %+v", axios)
+	}
+	if jest := findDep(deps, "jest"); jest == nil || jest.Kind != "dev" || jest.Line != 7 {
+		t.Errorf("unexpected jest: %+v", jest)
+	}
+}
+
+func TestJSONKeyOffset(t *testing.T) {
+	content := `{"name": "jest", "devDependencies": {"jest" :"^29.0.0"}}`
+	if got, want := jsonKeyOffset(content, 0, "jest"), strings.Index(content, `"jest" :`); got != want {
+		t.Errorf("expected the key rather than the value, got offset %d, want %d", got, want)
+	}
+	if got := jsonKeyOffset(content, 5, "missing"); got != 5 {
+		t.Errorf("expected from for a missing key, got %d", got)
+	}
+}
+
+func TestParseRequirements(t *testing.T) {
+	deps := parseRequirements("requirements-dev.txt", `# tooling
+-r requirements.txt
+pytest==8.0.0
+requests[security] >= 2.31 ; python_version >= "3.8"
+black  # formatter
+`)
+
+	if len(deps) != 3 {
+		t.Fatalf("expected 3 requirements, got %d: %+v", len(deps), deps)
+	}
+	if deps[0].Name != "pytest" || deps[0].Version != "==8.0.0" || deps[0].Kind != "dev" || deps[0].Line != 3 {
+		t.Errorf("unexpected pytest: %+v", deps[0])
+	}
+	if deps[1].Name != "requests" || deps[1].Version != ">=2.31" {
+		t.Errorf("unexpected requests: %+v", deps[1])
+	}
+	if deps[2].Name != "black" || deps[2].Version != "" {
+		t.Errorf("unexpected black: %+v", deps[2])
+	}
+}
+
+func TestParsePyproject(t *testing.T) {
+	deps := parsePyproject("pyproject.toml", `[project]
+name = "svc"
+dependencies = [
+    "httpx>=0.27",
+    "pydantic~=2.6",
+]
+
+[project.optional-dependencies]
+test = ["pytest"]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+fastapi = { version = "^0.110", extras = ["all"] }
+
+[tool.poetry.group.dev.dependencies]
+ruff = "^0.3"
+`)
+
+	if len(deps) != 5 {
+		t.Fatalf("expected 5 dependencies, got %d: %+v", len(deps), deps)
+	}
+	if d := findDep(deps, "pydantic"); d == nil || d.Version != "~=2.6" || d.Line != 5 {
+		t.Errorf("unexpected pydantic: %+v", d)
+	}
+	if d := findDep(deps, "pytest"); d == nil || d.Kind != "test" {
+		t.Errorf("unexpected pytest: %+v", d)
+	}
+	if d := findDep(deps, "fastapi"); d == nil || d.Version != "^0.110" {
+		t.Errorf("unexpected fastapi: %+v", d)
+	}
+	if d := findDep(deps, "ruff"); d == nil || d.Kind != "dev" {
+		t.Errorf("unexpected ruff: %+v", d)
+	}
+	if findDep(deps, "python") != nil {
+		t.Error("expected python constraint to be skipped")
+	}
+}
+
+func TestParseCargoToml(t *testing.T) {
+	deps := parseCargoToml("Cargo.toml", `[package]
+name = "cli"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+clap = "4"
+local = { path = "../local" }
+
+[dev-dependencies]
+insta = "1"
+
+[target.'cfg(unix)'.dependencies]
+nix = "0.28"
+
+[dependencies.tokio]
+version = "1.36"
+features = ["full"]
+`)
+
+	if len(deps) != 6 {
+		t.Fatalf("expected 6 dependencies, got %d: %+v", len(deps), deps)
+	}
+	if d := findDep(deps, "serde"); d == nil || d.Version != "1.0" || d.Line != 6 {
+		t.Errorf("unexpected serde: %+v", d)
+	}
+	if d := findDep(deps, "local"); d == nil || d.Version != "path:../local" {
+		t.Errorf("unexpected local: %+v", d)
+	}
+	if d := findDep(deps, "insta"); d == nil || d.Kind != "dev" {
+		t.Errorf("unexpected insta: %+v", d)
+	}
+	if d := findDep(deps, "nix"); d == nil || d.Version != "0.28" {
+		t.Errorf("unexpected nix: %+v", d)
+	}
+	if d := findDep(deps, "tokio"); d == nil || d.Version != "1.36" || d.Line != 16 {
+		t.Errorf("unexpected tokio: %+v", d)
+	}
+}
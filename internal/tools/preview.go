@@ -0,0 +1,544 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type PreviewDependencyInput struct {
+	Module  string `json:"module" jsonschema:"Go module path to preview, e.g. github.com/google/uuid"`
+	Version string `json:"version,omitempty" jsonschema:"module version or query, e.g. v1.6.0 (default latest, which needs a GOPROXY)"`
+	Path    string `json:"path,omitempty" jsonschema:"directory containing the project's go.mod (default .)"`
+	GoProxy string `json:"goproxy,omitempty" jsonschema:"GOPROXY to resolve modules with, e.g. file:///srv/goproxy or https://proxy.golang.org (default: preview.goproxy in .mtb.yaml, else off: the module cache only)"`
+}
+
+// PreviewModule is a module that adding the candidate would bring in or
+// move to another version.
+type PreviewModule struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Previous is the version go.mod requires today, for changed modules.
+	Previous string `json:"previous,omitempty"`
+	// License is the SPDX identifier of each license file found at the
+	// module root, or "unknown".
+	License    string `json:"license"`
+	Code       int64  `json:"code"`
+	Complexity int64  `json:"complexity"`
+}
+
+// PreviewDependencyOutput is a compare delta between the modules go.mod
+// builds today and the ones it would build with the candidate added, with
+// the go.mod and go.sum changes that would come with it.
+type PreviewDependencyOutput struct {
+	CompareOutput
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	GoProxy string `json:"goproxy"`
+	// GoSum lists the lines go.sum would gain.
+	GoSum []string `json:"goSum"`
+	// NewModules are the modules providing packages the candidate imports,
+	// directly or not, that go.mod does not require yet. The candidate is
+	// first.
+	NewModules []PreviewModule `json:"newModules"`
+	// Changed are the modules go.mod already requires whose version would
+	// change, usually an upgrade to satisfy the candidate, or a downgrade
+	// when previewing an older version of a required module.
+	Changed []PreviewModule `json:"changed,omitempty"`
+}
+
+// defaultGoProxy keeps previews offline unless a proxy is configured.
+const defaultGoProxy = "off"
+
+func HandlePreviewDependency(ctx context.Context, req *mcp.CallToolRequest, input PreviewDependencyInput) (*mcp.CallToolResult, PreviewDependencyOutput, error) {
+	if input.Module == "" {
+		return ErrResult[PreviewDependencyOutput]("module is required")
+	}
+	version := input.Version
+	if version == "" {
+		version = "latest"
+	}
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput]("invalid path: " + err.Error())
+	}
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+	model, err := resolveCocomo(CocomoModel{}, cfg.Cocomo)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+	proxy := input.GoProxy
+	if proxy == "" {
+		proxy = cfg.Preview.GoProxy
+	}
+	if proxy == "" {
+		proxy = defaultGoProxy
+	}
+
+	mod, err := newScratchModule(ctx, absPath, proxy)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+	defer mod.cleanup()
+
+	before, err := mod.state()
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+	if _, err := mod.run(ctx, "get", input.Module+"@"+version); err != nil {
+		msg := err.Error()
+		if proxy == "off" {
+			msg += " (with GOPROXY=off only modules already in the module cache can be previewed; set goproxy to resolve others)"
+		}
+		return ErrResult[PreviewDependencyOutput](msg)
+	}
+	candidate, err := mod.module(ctx, input.Module)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+	imported, err := mod.importedModules(ctx, input.Module)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+	after, err := mod.state()
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput](err.Error())
+	}
+
+	output := PreviewDependencyOutput{
+		Module:     input.Module,
+		Version:    candidate.Version,
+		GoProxy:    proxy,
+		GoSum:      []string{},
+		NewModules: []PreviewModule{},
+	}
+	for _, line := range after.sum {
+		if !before.hasSum[line] {
+			output.GoSum = append(output.GoSum, line)
+		}
+	}
+
+	// What the project builds today and would build with the candidate.
+	var oldDirs, newDirs []string
+	var newModules, changed []*goModule
+	for _, m := range imported {
+		if _, ok := before.requires[m.Path]; !ok {
+			newModules = append(newModules, m)
+		}
+	}
+	sort.Slice(newModules, func(i, j int) bool { return newModules[i].Path < newModules[j].Path })
+	if _, ok := before.requires[candidate.Path]; !ok {
+		newModules = append([]*goModule{candidate}, newModules...)
+	}
+	for p, v := range after.requires {
+		if old, ok := before.requires[p]; ok && old != v {
+			m, err := mod.module(ctx, p)
+			if err != nil {
+				return ErrResult[PreviewDependencyOutput](err.Error())
+			}
+			m.Previous = old
+			changed = append(changed, m)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+
+	for _, m := range changed {
+		if m.Replace == nil {
+			if prev, err := mod.download(ctx, m.Path+"@"+m.Previous); err == nil {
+				oldDirs = append(oldDirs, prev.Dir)
+			} else {
+				output.Warnings = append(output.Warnings, fmt.Sprintf("%s@%s is not in the module cache, so its current size counts as zero", m.Path, m.Previous))
+			}
+		}
+	}
+	all := append(append([]*goModule{}, newModules...), changed...)
+	for _, m := range all {
+		newDirs = append(newDirs, m.Dir)
+	}
+
+	stats, err := analyzeDirs(ctx, append(append([]string{}, oldDirs...), newDirs...), &model)
+	if err != nil {
+		return ErrResult[PreviewDependencyOutput]("analysis failed: " + err.Error())
+	}
+	var oldStats, newStats []*StatsOutput
+	for i, s := range stats {
+		if i < len(oldDirs) {
+			oldStats = append(oldStats, s)
+			continue
+		}
+		m := all[i-len(oldDirs)]
+		m.License = detectLicense(m.Dir)
+		for _, l := range s.LanguageSummary {
+			m.Code += l.Code
+			m.Complexity += l.Complexity
+		}
+		newStats = append(newStats, s)
+	}
+	for _, m := range newModules {
+		output.NewModules = append(output.NewModules, m.PreviewModule)
+	}
+	for _, m := range changed {
+		output.Changed = append(output.Changed, m.PreviewModule)
+	}
+
+	delta := diffStats(mergeStats(oldStats, model), mergeStats(newStats, model), model)
+	delta.Base = "go.mod"
+	delta.Head = "go.mod + " + input.Module + "@" + candidate.Version
+	delta.Table = renderDeltaTable(delta)
+	delta.Warnings = output.Warnings
+	output.CompareOutput = delta
+	for _, m := range all {
+		if copyleftLicense.MatchString(m.License) {
+			output.Warnings = append(output.Warnings, fmt.Sprintf("%s is licensed under %s", m.Path, m.License))
+		}
+	}
+	if proxy == "off" || strings.HasPrefix(proxy, "file://") {
+		output.Warnings = append(output.Warnings, "checksums were not verified against the checksum database")
+	}
+	output.Guidance = fmt.Sprintf("IMPORTANT: Present this preview to the user BEFORE adding %s. "+
+		"It would add %d go.sum lines and %d new modules, change the version of %d existing ones, and bring in %d lines of code "+
+		"with complexity %d (%s). Show the new modules with their licenses and the before/after table, "+
+		"and ask whether the capability is worth that much code. Run check_dependency first if you have not: "+
+		"the project or the standard library may already cover it. Do NOT run go get until the user agrees.",
+		input.Module, len(output.GoSum), len(output.NewModules), len(output.Changed),
+		output.Total.Code.Delta, output.Total.Complexity.Delta, FormatMoney(output.Total.EstimatedCost.Delta, model.Currency))
+	for _, w := range output.Warnings {
+		output.Guidance += " WARNING: " + w + "."
+	}
+
+	summary := fmt.Sprintf("Preview of %s@%s: %d new modules, %d changed, %d new go.sum lines.\n\n%s",
+		input.Module, candidate.Version, len(output.NewModules), len(output.Changed), len(output.GoSum), output.Table)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+	}, output, nil
+}
+
+// goModule is a module as go list -m -json and go mod download -json
+// describe it.
+type goModule struct {
+	PreviewModule
+	Dir     string
+	Replace *struct{ Path, Version, Dir string }
+}
+
+func (m *goModule) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Path, Version, Dir string
+		Replace            *struct{ Path, Version, Dir string }
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Path, m.Version, m.Dir, m.Replace = raw.Path, raw.Version, raw.Dir, raw.Replace
+	if m.Replace != nil && m.Replace.Dir != "" {
+		m.Dir = m.Replace.Dir
+	}
+	return nil
+}
+
+// scratchModule is a copy of the project's go.mod and go.sum in a temporary
+// directory, so go get can run without touching the project.
+type scratchModule struct {
+	dir   string
+	env   []string
+	proxy string
+}
+
+func newScratchModule(ctx context.Context, projectDir, proxy string) (*scratchModule, error) {
+	gomod, err := os.ReadFile(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("preview_dependency needs a go.mod in %s: %w", projectDir, err)
+	}
+	tmp, err := os.MkdirTemp("", "mtb-preview-*")
+	if err != nil {
+		return nil, err
+	}
+	m := &scratchModule{dir: tmp, proxy: proxy}
+	m.env = append(os.Environ(),
+		"GOPROXY="+proxy,
+		"GOFLAGS="+strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=mod"),
+		"GOWORK=off",
+		"GOTOOLCHAIN=local",
+	)
+	// A file:// proxy is used to work offline, where the checksum database
+	// cannot be reached either.
+	if proxy == "off" || strings.HasPrefix(proxy, "file://") {
+		m.env = append(m.env, "GOSUMDB=off")
+	}
+
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), gomod, 0644); err != nil {
+		m.cleanup()
+		return nil, err
+	}
+	if sum, err := os.ReadFile(filepath.Join(projectDir, "go.sum")); err == nil {
+		if err := os.WriteFile(filepath.Join(tmp, "go.sum"), sum, 0644); err != nil {
+			m.cleanup()
+			return nil, err
+		}
+	}
+
+	// Relative replacements point into the project, not the copy.
+	out, err := m.run(ctx, "mod", "edit", "-json")
+	if err != nil {
+		m.cleanup()
+		return nil, err
+	}
+	var file struct {
+		Replace []struct {
+			Old, New struct{ Path, Version string }
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &file); err != nil {
+		m.cleanup()
+		return nil, err
+	}
+	for _, r := range file.Replace {
+		if !strings.HasPrefix(r.New.Path, ".") {
+			continue
+		}
+		old := r.Old.Path
+		if r.Old.Version != "" {
+			old += "@" + r.Old.Version
+		}
+		if _, err := m.run(ctx, "mod", "edit", "-replace="+old+"="+filepath.Join(projectDir, r.New.Path)); err != nil {
+			m.cleanup()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *scratchModule) cleanup() { os.RemoveAll(m.dir) }
+
+// run runs the go command in the scratch module and returns its stdout.
+// Failures include go's stderr.
+func (m *scratchModule) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = m.dir
+	cmd.Env = m.env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("go %s: %s", strings.Join(args, " "), msg)
+	}
+	return stdout.String(), nil
+}
+
+// module describes a module in the scratch module's build list, downloading
+// its source when go get only needed its go.mod.
+func (m *scratchModule) module(ctx context.Context, path string) (*goModule, error) {
+	out, err := m.run(ctx, "list", "-m", "-json", path)
+	if err != nil {
+		return nil, err
+	}
+	var mod goModule
+	if err := json.Unmarshal([]byte(out), &mod); err != nil {
+		return nil, err
+	}
+	if mod.Dir == "" {
+		downloaded, err := m.download(ctx, mod.Path+"@"+mod.Version)
+		if err != nil {
+			return nil, err
+		}
+		mod.Dir = downloaded.Dir
+	}
+	return &mod, nil
+}
+
+// download fetches a module version into the module cache, or finds it
+// there, and describes it.
+func (m *scratchModule) download(ctx context.Context, query string) (*goModule, error) {
+	out, err := m.run(ctx, "mod", "download", "-json", query)
+	if err != nil {
+		return nil, err
+	}
+	var mod goModule
+	if err := json.Unmarshal([]byte(out), &mod); err != nil {
+		return nil, err
+	}
+	return &mod, nil
+}
+
+// importedModules returns the modules providing the packages that the
+// candidate module's packages import, directly or not, leaving out the
+// standard library and the candidate itself.
+func (m *scratchModule) importedModules(ctx context.Context, path string) ([]*goModule, error) {
+	out, err := m.run(ctx, "list", "-e", "-deps", "-f", "{{with .Module}}{{.Path}}{{end}}", path+"/...")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{path: true}
+	var mods []*goModule
+	for _, p := range strings.Fields(out) {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		mod, err := m.module(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+// moduleState is what go.mod requires and the lines of go.sum.
+type moduleState struct {
+	requires map[string]string
+	sum      []string
+	hasSum   map[string]bool
+}
+
+func (m *scratchModule) state() (moduleState, error) {
+	s := moduleState{requires: map[string]string{}, hasSum: map[string]bool{}}
+	gomod, err := os.ReadFile(filepath.Join(m.dir, "go.mod"))
+	if err != nil {
+		return s, err
+	}
+	for _, d := range parseGoMod("go.mod", string(gomod)) {
+		s.requires[d.Name] = d.Version
+	}
+	sum, _ := os.ReadFile(filepath.Join(m.dir, "go.sum"))
+	for _, line := range strings.Split(string(sum), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !s.hasSum[line] {
+			s.hasSum[line] = true
+			s.sum = append(s.sum, line)
+		}
+	}
+	return s, nil
+}
+
+// analyzeDirs runs scc on each directory in parallel.
+func analyzeDirs(ctx context.Context, dirs []string, model *CocomoModel) ([]*StatsOutput, error) {
+	stats := make([]*StatsOutput, len(dirs))
+	errs := make([]error, len(dirs))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Go(func() { stats[i], errs[i] = RunSCC(ctx, dir, model, true, nil, nil, nil) })
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dirs[i], err)
+		}
+	}
+	return stats, nil
+}
+
+// mergeStats adds up several analyses per language, estimating the cost of
+// the combined code as one codebase.
+func mergeStats(stats []*StatsOutput, model CocomoModel) *StatsOutput {
+	merged := &StatsOutput{Cocomo: &model}
+	index := map[string]int{}
+	var code int64
+	for _, s := range stats {
+		for _, l := range s.LanguageSummary {
+			i, ok := index[l.Name]
+			if !ok {
+				i = len(merged.LanguageSummary)
+				index[l.Name] = i
+				merged.LanguageSummary = append(merged.LanguageSummary, LanguageSummary{Name: l.Name})
+			}
+			m := &merged.LanguageSummary[i]
+			m.Bytes += l.Bytes
+			m.Lines += l.Lines
+			m.Code += l.Code
+			m.Comment += l.Comment
+			m.Blank += l.Blank
+			m.Complexity += l.Complexity
+			m.Count += l.Count
+			code += l.Code
+		}
+	}
+	merged.EstimatedCost = model.cost(code)
+	return merged
+}
+
+// licenseFile matches the license files Go's module proxy and pkg.go.dev
+// look for at a module root.
+var licenseFile = regexp.MustCompile(`(?i)^(licen[cs]e|copying)([.-].*)?$`)
+
+// copyleftLicense matches licenses that place conditions on the project
+// that links the code.
+var copyleftLicense = regexp.MustCompile(`GPL|MPL|EPL|EUPL|OSL`)
+
+// licenseMarkers identify common licenses by a phrase from their text, in
+// the order they are tried.
+var licenseMarkers = []struct{ id, phrase string }{
+	{"AGPL-3.0", "GNU AFFERO GENERAL PUBLIC LICENSE"},
+	{"LGPL-3.0", "GNU LESSER GENERAL PUBLIC LICENSE\n                       Version 3"},
+	{"LGPL-2.1", "GNU LESSER GENERAL PUBLIC LICENSE"},
+	{"GPL-3.0", "GNU GENERAL PUBLIC LICENSE\n                       Version 3"},
+	{"GPL-2.0", "GNU GENERAL PUBLIC LICENSE"},
+	{"Apache-2.0", "Apache License"},
+	{"MPL-2.0", "Mozilla Public License"},
+	{"EPL-2.0", "Eclipse Public License"},
+	{"MIT", "Permission is hereby granted, free of charge"},
+	{"BSD-3-Clause", "Neither the name"},
+	{"BSD-3-Clause", "names of its contributors"},
+	{"BSD-2-Clause", "Redistribution and use in source and binary forms"},
+	{"ISC", "Permission to use, copy, modify, and/or distribute this software for any"},
+	{"Unlicense", "This is free and unencumbered software released into the public domain"},
+	{"CC0-1.0", "CC0 1.0 Universal"},
+}
+
+// detectLicense identifies the license files at a module root and returns
+// their SPDX identifiers, e.g. "MIT" or "Apache-2.0, MIT" for a dual
+// license, or "unknown".
+func detectLicense(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "unknown"
+	}
+	found := map[string]bool{}
+	for _, e := range entries {
+		if e.IsDir() || !licenseFile.MatchString(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		text := strings.Join(strings.Fields(string(data)), " ")
+		for _, m := range licenseMarkers {
+			if strings.Contains(text, strings.Join(strings.Fields(m.phrase), " ")) {
+				found[m.id] = true
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		return "unknown"
+	}
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// writeGoProxy lays out a file:// GOPROXY serving the given module versions,
+// each a map of file names to contents including go.mod.
+func writeGoProxy(t *testing.T, dir string, modules map[string]map[string]string) {
+	t.Helper()
+	versions := map[string][]string{}
+	for mv, files := range modules {
+		path, version, _ := strings.Cut(mv, "@")
+		versions[path] = append(versions[path], version)
+		base := filepath.Join(dir, filepath.FromSlash(path), "@v")
+		writeFiles(t, base, map[string]string{
+			version + ".info": `{"Version":"` + version + `","Time":"2024-01-01T00:00:00Z"}`,
+			version + ".mod":  files["go.mod"],
+		})
+		f, err := os.Create(filepath.Join(base, version+".zip"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(f)
+		for name, content := range files {
+			w, err := zw.Create(mv + "/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, vs := range versions {
+		writeFiles(t, filepath.Join(dir, filepath.FromSlash(path), "@v"), map[string]string{
+			"list": strings.Join(vs, "\n") + "\n",
+		})
+	}
+}
+
+func TestHandlePreviewDependency(t *testing.T) {
+	proxy := t.TempDir()
+	dir := t.TempDir()
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "-modcacherw")
+
+	mit := "Permission is hereby granted, free of charge, to any person obtaining a copy\n"
+	writeGoProxy(t, proxy, map[string]map[string]string{
+		"example.com/dep@v1.0.0": {
+			"go.mod":  "module example.com/dep\n\ngo 1.21\n",
+			"LICENSE": mit,
+			"dep.go":  "package dep\n\nfunc Dep() {}\n",
+		},
+		"example.com/dep@v1.1.0": {
+			"go.mod":  "module example.com/dep\n\ngo 1.21\n",
+			"LICENSE": mit,
+			"dep.go":  "package dep\n\nfunc Dep() {}\n\nfunc More() int {\n\treturn 1\n}\n",
+		},
+		"example.com/extra@v0.1.0": {
+			"go.mod":     "module example.com/extra\n\ngo 1.21\n",
+			"COPYING":    "                    GNU GENERAL PUBLIC LICENSE\n                       Version 3, 29 June 2007\n",
+			"extra.go":   "package extra\n\nfunc Extra() {}\n",
+			"unused.txt": "not code\n",
+		},
+		"example.com/unused@v1.0.0": {
+			"go.mod":    "module example.com/unused\n\ngo 1.21\n",
+			"unused.go": "package unused\n",
+		},
+		"example.com/cand@v1.0.0": {
+			"go.mod":  "module example.com/cand\n\ngo 1.21\n\nrequire (\n\texample.com/dep v1.1.0\n\texample.com/extra v0.1.0\n\texample.com/unused v1.0.0\n)\n",
+			"LICENSE": "                                 Apache License\n                           Version 2.0, January 2004\n",
+			"cand.go": "package cand\n\nimport (\n\t\"example.com/dep\"\n\t\"example.com/extra\"\n)\n\n" + complexGo[len("package main\n"):],
+		},
+	})
+	writeFiles(t, dir, map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n\nrequire example.com/dep v1.0.0\n",
+		"main.go": "package main\n\nimport \"example.com/dep\"\n\nfunc main() { dep.Dep() }\n",
+	})
+	gomod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+
+	_, out, err := HandlePreviewDependency(context.Background(), &mcp.CallToolRequest{}, PreviewDependencyInput{
+		Module:  "example.com/cand",
+		Version: "v1.0.0",
+		Path:    dir,
+		GoProxy: "file://" + filepath.ToSlash(proxy),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Version != "v1.0.0" || out.Head != "go.mod + example.com/cand@v1.0.0" {
+		t.Errorf("unexpected version or labels: %+v", out)
+	}
+	if len(out.NewModules) != 2 || out.NewModules[0].Path != "example.com/cand" || out.NewModules[1].Path != "example.com/extra" {
+		t.Fatalf("expected cand and extra to be new, and unused to be left out, got %+v", out.NewModules)
+	}
+	if out.NewModules[0].License != "Apache-2.0" || out.NewModules[1].License != "GPL-3.0" || out.NewModules[0].Complexity == 0 {
+		t.Errorf("unexpected licenses or sizes: %+v", out.NewModules)
+	}
+	if len(out.Changed) != 1 || out.Changed[0].Path != "example.com/dep" || out.Changed[0].Previous != "v1.0.0" ||
+		out.Changed[0].Version != "v1.1.0" || out.Changed[0].License != "MIT" {
+		t.Errorf("expected dep to be upgraded, got %+v", out.Changed)
+	}
+	var candSum bool
+	for _, line := range out.GoSum {
+		candSum = candSum || strings.HasPrefix(line, "example.com/cand v1.0.0 h1:")
+	}
+	if !candSum {
+		t.Errorf("expected new go.sum lines for cand, got %v", out.GoSum)
+	}
+	if out.Total.Code.Before == 0 || out.Total.Code.After <= out.Total.Code.Before || out.Total.Complexity.Delta == 0 {
+		t.Errorf("expected the old dep as the base and the added code as the head, got %+v", out.Total)
+	}
+	if !strings.Contains(out.Table, "| Go |") || !strings.Contains(strings.Join(out.Warnings, "\n"), "example.com/extra is licensed under GPL-3.0") {
+		t.Errorf("unexpected table or warnings: %s %v", out.Table, out.Warnings)
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "go.mod")); string(after) != string(gomod) {
+		t.Errorf("the project's go.mod was modified:\n%s", after)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.sum")); err == nil {
+		t.Error("the project's go.sum was created")
+	}
+}
+
+func TestHandlePreviewDependency_Offline(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOMODCACHE", t.TempDir())
+	writeFiles(t, dir, map[string]string{"go.mod": "module example.com/app\n\ngo 1.21\n"})
+
+	result, _, _ := HandlePreviewDependency(context.Background(), &mcp.CallToolRequest{}, PreviewDependencyInput{
+		Module: "example.com/missing",
+		Path:   dir,
+	})
+	if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "module cache") {
+		t.Errorf("expected an error pointing at the module cache, got %+v", result)
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"LICENSE-MIT":    "Permission is hereby granted, free of charge, to any person\nobtaining a copy",
+		"LICENSE-APACHE": "Apache License\nVersion 2.0",
+		"license.go":     "package license\n",
+	})
+	if got := detectLicense(dir); got != "Apache-2.0, MIT" {
+		t.Errorf("expected a dual license, got %q", got)
+	}
+	if got := detectLicense(t.TempDir()); got != "unknown" {
+		t.Errorf("expected unknown without a license file, got %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Prompts let users start mtb's flows themselves, e.g. as slash commands,
+// instead of relying on the agent to pick the tool. Each one runs the
+// matching tool and expands its output into a user message.
+
+func HandleConsultPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs(req)
+	result, out, err := HandleConsult(ctx, &mcp.CallToolRequest{}, ConsultInput{
+		Problem:  args["problem"],
+		Path:     args["path"],
+		Language: args["language"],
+	})
+	if err := toolError(result, err); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "I'm thinking about building: %q. Before any code is written, walk me through these questions one at a time "+
+		"and wait for my answer to each. Push back if an answer is vague, and don't answer them for me.\n\n", args["problem"])
+	for i, q := range out.Questions {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, q)
+	}
+	if len(out.Alternatives) > 0 {
+		b.WriteString("\nExisting solutions to weigh against building it:\n\n")
+		for _, alt := range out.Alternatives {
+			fmt.Fprintf(&b, "- %s (%s, %s, %s) %s\n", alt.Name, alt.Kind, alt.License, alt.Deploy, alt.URL)
+		}
+	}
+	if len(out.Repositories) > 0 {
+		b.WriteString("\nMatching repositories:\n\n")
+		for _, repo := range out.Repositories {
+			fmt.Fprintf(&b, "- %s (%d stars, %s, last pushed %s) %s\n", repo.FullName, repo.Stars, orNone(repo.License), repo.PushedAt.Format("2006-01-02"), repo.URL)
+		}
+	}
+	if out.SessionID != "" {
+		fmt.Fprintf(&b, "\nRecord my answers with the consult_answer tool (session_id %q) and ask the follow-up questions it returns. ", out.SessionID)
+	} else {
+		b.WriteString("\n")
+	}
+	b.WriteString("Once every question is answered, help me decide whether to build, buy/adopt, or abandon this.")
+
+	return userPrompt("Consultation before building "+args["problem"], b.String()), nil
+}
+
+func HandleChecklistPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs(req)
+	result, out, err := HandleChecklist(ctx, &mcp.CallToolRequest{}, ChecklistInput{
+		Project: args["project"],
+		Path:    args["path"],
+	})
+	if err := toolError(result, err); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Let's review the operational readiness of %q. Go through each item one at a time, ask me whether it is addressed, "+
+		"partially addressed, or not addressed, and wait for my answer. Don't answer for me.\n\n", args["project"])
+	for i, item := range out.Items {
+		fmt.Fprintf(&b, "%d. **%s**: %s %s", i+1, item.Category, item.Question, item.Description)
+		if item.Status != "" {
+			fmt.Fprintf(&b, " (mtb's scan: %s", item.Status)
+			if len(item.Evidence) > 0 {
+				fmt.Fprintf(&b, "; %s", strings.Join(item.Evidence, ", "))
+			}
+			b.WriteString(")")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nFor anything not addressed, suggest concrete next steps to close the gap.")
+
+	return userPrompt("Operational readiness checklist for "+args["project"], b.String()), nil
+}
+
+func HandleComparePrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := promptArgs(req)
+	result, out, err := HandleCompare(ctx, &mcp.CallToolRequest{}, CompareInput{
+		Project:      args["project"],
+		Path:         args["path"],
+		Base:         args["base"],
+		Head:         args["head"],
+		BaseSnapshot: args["base_snapshot"],
+	})
+	if err := toolError(result, err); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Here is how my changes to %q moved complexity, from %s to %s:\n\n%s\n", args["project"], out.Base, out.Head, out.Table)
+	for _, w := range out.Warnings {
+		fmt.Fprintf(&b, "Warning: %s\n", w)
+	}
+	b.WriteString("\nWalk me through where the complexity was added and whether it is justified. " +
+		"If it isn't, suggest how to simplify the change before I commit it.")
+
+	return userPrompt(fmt.Sprintf("Complexity impact of %s → %s", out.Base, out.Head), b.String()), nil
+}
+
+func promptArgs(req *mcp.GetPromptRequest) map[string]string {
+	if req == nil || req.Params == nil || req.Params.Arguments == nil {
+		return map[string]string{}
+	}
+	return req.Params.Arguments
+}
+
+// toolError converts a tool's error result into an error for a prompt.
+func toolError(result *mcp.CallToolResult, err error) error {
+	if err != nil || result == nil || !result.IsError {
+		return err
+	}
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return errors.New(strings.Join(parts, "\n"))
+}
+
+func userPrompt(description, text string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{
+			{Role: "user", Content: &mcp.TextContent{Text: text}},
+		},
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "no license"
+	}
+	return s
+}
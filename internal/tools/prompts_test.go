@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func getPrompt(t *testing.T, h mcp.PromptHandler, args map[string]string) string {
+	t.Helper()
+	res, err := h(context.Background(), &mcp.GetPromptRequest{Params: &mcp.GetPromptParams{Arguments: args}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Messages) != 1 || res.Messages[0].Role != "user" {
+		t.Fatalf("expected a single user message, got %+v", res.Messages)
+	}
+	return res.Messages[0].Content.(*mcp.TextContent).Text
+}
+
+func TestConsultPrompt(t *testing.T) {
+	dir := t.TempDir()
+	text := getPrompt(t, HandleConsultPrompt, map[string]string{"problem": "a helpdesk", "path": dir})
+
+	for _, want := range []string{`"a helpdesk"`, "1. What is the actual problem", "7. If you build this", "- Zammad (oss, AGPL-3.0", "consult_answer tool (session_id"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in the prompt:\n%s", want, text)
+		}
+	}
+}
+
+func TestChecklistPrompt(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{".github/workflows/ci.yml": "on: push\n"})
+	text := getPrompt(t, HandleChecklistPrompt, map[string]string{"project": "billing", "path": dir})
+
+	if !strings.Contains(text, "1. **Automated tests / CI**") || !strings.Contains(text, ".github/workflows/ci.yml") {
+		t.Errorf("expected checklist items with evidence in the prompt:\n%s", text)
+	}
+}
+
+func TestComparePrompt(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {\n\tif true {\n\t\tprintln(1)\n\t}\n}\n"})
+	text := getPrompt(t, HandleComparePrompt, map[string]string{"project": "scratch", "path": dir})
+
+	if !strings.Contains(text, "from HEAD to working tree") || !strings.Contains(text, "| Go |") {
+		t.Errorf("expected the comparison table in the prompt:\n%s", text)
+	}
+}
+
+func TestPrompts_MissingArguments(t *testing.T) {
+	for name, h := range map[string]mcp.PromptHandler{
+		"consult":   HandleConsultPrompt,
+		"checklist": HandleChecklistPrompt,
+		"compare":   HandleComparePrompt,
+	} {
+		if _, err := h(context.Background(), &mcp.GetPromptRequest{Params: &mcp.GetPromptParams{}}); err == nil {
+			t.Errorf("%s: expected an error without arguments", name)
+		}
+	}
+}
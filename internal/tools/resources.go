@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resource URIs. Resources that read a directory take it as a path query
+// parameter, defaulting to the server's working directory.
+const (
+	statsLatestURI = "mtb://stats/latest"
+	catalogURI     = "mtb://catalog"
+)
+
+// LatestStats is the most recent stats result for a directory.
+type LatestStats struct {
+	Path       string      `json:"path"`
+	AnalyzedAt time.Time   `json:"analyzedAt"`
+	Stats      StatsOutput `json:"stats"`
+}
+
+// maxLatestStats bounds how many directories' latest analyses are kept, so a
+// long-running HTTP server does not grow with every path it ever analyzed.
+const maxLatestStats = 16
+
+// resourceHub remembers the latest analyses and the servers to notify when
+// they change. The servers track which sessions subscribed to what.
+var resourceHub struct {
+	mu      sync.Mutex
+	servers []*mcp.Server
+	// latest holds the most recent analyses; order lists their paths from
+	// least to most recently used.
+	latest map[string]LatestStats
+	order  []string
+}
+
+// AddResources publishes mtb's resources on server and sends it
+// resource-updated notifications. The server must be created with
+// SubscribeResource and UnsubscribeResource as its subscription handlers.
+func AddResources(server *mcp.Server) {
+	server.AddResource(&mcp.Resource{
+		URI:         statsLatestURI,
+		Name:        "stats-latest",
+		Title:       "Latest stats",
+		Description: "The most recent stats result for the working directory, analyzing it if there is none yet.",
+		MIMEType:    "application/json",
+	}, readLatestStats)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: statsLatestURI + "{?path}",
+		Name:        "stats-latest-path",
+		Title:       "Latest stats for a directory",
+		Description: "The most recent stats result for a directory. Subscribe to be notified when a new analysis of it finishes.",
+		MIMEType:    "application/json",
+	}, readLatestStats)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: "mtb://snapshots/{name}{?path}",
+		Name:        "snapshot",
+		Title:       "Saved snapshot",
+		Description: "A named stats snapshot saved with the snapshot tool in the repository containing path.",
+		MIMEType:    "application/json",
+	}, readSnapshot)
+	server.AddResource(&mcp.Resource{
+		URI:         catalogURI,
+		Name:        "catalog",
+		Title:       "Catalog of existing solutions",
+		Description: "Every problem domain in mtb's embedded catalog, with its known open-source and SaaS alternatives.",
+		MIMEType:    "application/json",
+	}, readCatalog)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: catalogURI + "/{domain}",
+		Name:        "catalog-domain",
+		Title:       "Catalog domain",
+		Description: "One problem domain from mtb's catalog, e.g. helpdesk or wiki, with its known alternatives.",
+		MIMEType:    "application/json",
+	}, readCatalog)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: "mtb://checklist/{project}{?path}",
+		Name:        "checklist",
+		Title:       "Operational readiness checklist",
+		Description: "The checklist for a project, with evidence detected in path when given.",
+		MIMEType:    "application/json",
+	}, readChecklist)
+
+	resourceHub.mu.Lock()
+	defer resourceHub.mu.Unlock()
+	resourceHub.servers = append(resourceHub.servers, server)
+}
+
+// SubscribeResource accepts a client's subscription to one of mtb's
+// resources. The server records the subscription for its session.
+func SubscribeResource(ctx context.Context, req *mcp.SubscribeRequest) error {
+	u, err := url.Parse(req.Params.URI)
+	if err != nil || u.Scheme != "mtb" {
+		return mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	return nil
+}
+
+// UnsubscribeResource accepts a client's unsubscription; the server drops
+// its session's subscription.
+func UnsubscribeResource(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	return nil
+}
+
+// publishStats makes output the latest stats for absPath and notifies
+// subscribers of resources showing it.
+func publishStats(ctx context.Context, absPath string, output StatsOutput) {
+	resourceHub.mu.Lock()
+	if resourceHub.latest == nil {
+		resourceHub.latest = map[string]LatestStats{}
+	}
+	resourceHub.latest[absPath] = LatestStats{Path: absPath, AnalyzedAt: time.Now().UTC(), Stats: output}
+	touchLatest(absPath)
+	resourceHub.mu.Unlock()
+
+	notifyResources(ctx, statsLatestURI, absPath)
+}
+
+// touchLatest marks path as the most recently used analysis and evicts the
+// least recently used ones beyond maxLatestStats. The caller holds
+// resourceHub.mu.
+func touchLatest(path string) {
+	resourceHub.order = append(slices.DeleteFunc(resourceHub.order, func(p string) bool { return p == path }), path)
+	for len(resourceHub.order) > maxLatestStats {
+		delete(resourceHub.latest, resourceHub.order[0])
+		resourceHub.order = resourceHub.order[1:]
+	}
+}
+
+// publishSnapshot notifies subscribers of a snapshot that was saved or
+// deleted in the store at root by a call for absPath.
+func publishSnapshot(ctx context.Context, root, absPath, name string) {
+	uri := "mtb://snapshots/" + url.PathEscape(name)
+	notifyResources(ctx, uri, root)
+	if absPath != root {
+		notifyResources(ctx, uri, absPath)
+	}
+}
+
+// notifyResources sends a resource-updated notification for each way a
+// client may have written the URI of resource uri for directory dir: with
+// dir as its path parameter, percent-encoded or not, or without one when
+// dir is the working directory. The servers only notify sessions that
+// subscribed to that exact URI.
+func notifyResources(ctx context.Context, uri, dir string) {
+	uris := []string{uri + "?path=" + url.QueryEscape(dir)}
+	if raw := uri + "?path=" + filepath.ToSlash(dir); raw != uris[0] {
+		uris = append(uris, raw)
+	}
+	if wd, err := filepath.Abs("."); err == nil && wd == dir {
+		uris = append(uris, uri)
+	}
+
+	resourceHub.mu.Lock()
+	servers := slices.Clone(resourceHub.servers)
+	resourceHub.mu.Unlock()
+	for _, s := range servers {
+		for _, u := range uris {
+			s.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: u})
+		}
+	}
+}
+
+// resourcePath returns the absolute directory named by a resource URI's
+// path parameter.
+func resourcePath(u *url.URL) string {
+	path := u.Query().Get("path")
+	if path == "" {
+		path = "."
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+func readLatestStats(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	u, err := url.Parse(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	absPath := resourcePath(u)
+
+	resourceHub.mu.Lock()
+	latest, ok := resourceHub.latest[absPath]
+	if ok {
+		touchLatest(absPath)
+	}
+	resourceHub.mu.Unlock()
+	if !ok {
+		result, _, err := HandleStats(ctx, &mcp.CallToolRequest{}, StatsInput{Path: absPath})
+		if err := toolError(result, err); err != nil {
+			return nil, err
+		}
+		resourceHub.mu.Lock()
+		latest = resourceHub.latest[absPath]
+		resourceHub.mu.Unlock()
+	}
+	return jsonResource(req.Params.URI, latest)
+}
+
+func readSnapshot(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	u, err := url.Parse(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := loadSnapshot(ctx, resourcePath(u), strings.TrimPrefix(u.Path, "/"))
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	return jsonResource(req.Params.URI, snap)
+}
+
+func readCatalog(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	u, err := url.Parse(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	domains := loadCatalog()
+	id := strings.TrimPrefix(u.Path, "/")
+	if id == "" {
+		return jsonResource(req.Params.URI, domains)
+	}
+	i := slices.IndexFunc(domains, func(d CatalogDomain) bool { return d.ID == id })
+	if i < 0 {
+		return nil, mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	return jsonResource(req.Params.URI, domains[i])
+}
+
+func readChecklist(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	u, err := url.Parse(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	input := ChecklistInput{Project: strings.TrimPrefix(u.Path, "/")}
+	if u.Query().Has("path") {
+		input.Path = resourcePath(u)
+	}
+	result, output, err := HandleChecklist(ctx, &mcp.CallToolRequest{}, input)
+	if err := toolError(result, err); err != nil {
+		return nil, err
+	}
+	return jsonResource(req.Params.URI, output)
+}
+
+func jsonResource(uri string, v any) (*mcp.ReadResourceResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: uri, MIMEType: "application/json", Text: string(data)},
+	}}, nil
+}
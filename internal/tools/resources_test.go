@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connectResources serves mtb's resources, stats, and snapshot to an
+// in-memory client that reports resource updates on updated.
+func connectResources(t *testing.T, updated chan<- string) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "mtb", Version: "test"}, &mcp.ServerOptions{
+		SubscribeHandler:   SubscribeResource,
+		UnsubscribeHandler: UnsubscribeResource,
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "stats"}, HandleStats)
+	mcp.AddTool(server, &mcp.Tool{Name: "snapshot"}, HandleSnapshot)
+	AddResources(server)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, &mcp.ClientOptions{
+		ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func readJSON(t *testing.T, session *mcp.ClientSession, uri string, v any) {
+	t.Helper()
+	res, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		t.Fatalf("reading %s: %v", uri, err)
+	}
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), v); err != nil {
+		t.Fatalf("reading %s: %v", uri, err)
+	}
+}
+
+func TestResources_StatsLatest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	updated := make(chan string, 10)
+	session := connectResources(t, updated)
+	ctx := context.Background()
+
+	uri := "mtb://stats/latest?path=" + url.QueryEscape(dir)
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	defer session.Unsubscribe(ctx, &mcp.UnsubscribeParams{URI: uri})
+
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "stats", Arguments: StatsInput{Path: dir}}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-updated:
+		if got != uri {
+			t.Fatalf("expected an update for %s, got %s", uri, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a resource-updated notification")
+	}
+
+	var latest LatestStats
+	readJSON(t, session, uri, &latest)
+	if latest.Path != dir || len(latest.Stats.LanguageSummary) != 1 || latest.Stats.LanguageSummary[0].Name != "Go" {
+		t.Fatalf("unexpected latest stats: %+v", latest)
+	}
+}
+
+func TestResources_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	updated := make(chan string, 10)
+	session := connectResources(t, updated)
+	ctx := context.Background()
+
+	uri := "mtb://snapshots/before?path=" + url.QueryEscape(dir)
+	if _, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri}); err == nil {
+		t.Fatal("expected an error for a missing snapshot")
+	}
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	defer session.Unsubscribe(ctx, &mcp.UnsubscribeParams{URI: uri})
+
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "snapshot", Arguments: SnapshotInput{Name: "before", Path: dir}}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a resource-updated notification")
+	}
+
+	var snap Snapshot
+	readJSON(t, session, uri, &snap)
+	if snap.Name != "before" || totalCode(snap.Stats) != 3 {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+}
+
+func TestResources_CatalogAndChecklist(t *testing.T) {
+	session := connectResources(t, make(chan string, 10))
+
+	var domains []CatalogDomain
+	readJSON(t, session, "mtb://catalog", &domains)
+	if len(domains) == 0 {
+		t.Fatal("expected catalog domains")
+	}
+
+	var helpdesk CatalogDomain
+	readJSON(t, session, "mtb://catalog/helpdesk", &helpdesk)
+	if helpdesk.ID != "helpdesk" || len(helpdesk.Alternatives) == 0 {
+		t.Fatalf("unexpected domain: %+v", helpdesk)
+	}
+	if _, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "mtb://catalog/nope"}); err == nil {
+		t.Fatal("expected an error for an unknown domain")
+	}
+
+	var checklist ChecklistOutput
+	readJSON(t, session, "mtb://checklist/billing%20service", &checklist)
+	if len(checklist.Items) == 0 || !strings.Contains(checklist.Guidance, `"billing service"`) {
+		t.Fatalf("unexpected checklist: %+v", checklist)
+	}
+}
+
+func TestPublishStats_Bounded(t *testing.T) {
+	ctx := context.Background()
+	first := t.TempDir()
+	publishStats(ctx, first, StatsOutput{})
+	for i := 0; i < maxLatestStats; i++ {
+		publishStats(ctx, t.TempDir(), StatsOutput{})
+	}
+
+	resourceHub.mu.Lock()
+	defer resourceHub.mu.Unlock()
+	if _, ok := resourceHub.latest[first]; ok || len(resourceHub.latest) > maxLatestStats || len(resourceHub.order) != len(resourceHub.latest) {
+		t.Errorf("expected the oldest analysis to be evicted, keeping %d of %d", len(resourceHub.latest), maxLatestStats)
+	}
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repository is a source repository found by a SearchProvider.
+type Repository struct {
+	FullName    string    `json:"fullName"`
+	Description string    `json:"description,omitempty"`
+	URL         string    `json:"url"`
+	Stars       int       `json:"stars"`
+	License     string    `json:"license,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	PushedAt    time.Time `json:"pushedAt"`
+}
+
+// SearchProvider finds existing repositories that may already solve a
+// problem.
+type SearchProvider interface {
+	// SearchRepositories returns up to limit repositories matching query,
+	// most popular first. A non-empty language restricts results to
+	// repositories written in it.
+	SearchRepositories(ctx context.Context, query, language string, limit int) ([]Repository, error)
+}
+
+// OfflineSearch is a SearchProvider that never finds anything, for
+// air-gapped environments where the embedded catalog is the only source.
+type OfflineSearch struct{}
+
+func (OfflineSearch) SearchRepositories(context.Context, string, string, int) ([]Repository, error) {
+	return nil, nil
+}
+
+// DefaultGitHubURL is the GitHub REST API. GitHub Enterprise serves the same
+// API under https://<host>/api/v3.
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHubSearch searches repositories with the GitHub REST API.
+type GitHubSearch struct {
+	BaseURL string
+	// Token is sent as a bearer token when set. Unauthenticated requests
+	// are limited to 10 searches a minute.
+	Token  string
+	Client *http.Client
+}
+
+// NewGitHubSearch returns a GitHubSearch for the API at baseURL, or
+// api.github.com when baseURL is empty.
+func NewGitHubSearch(baseURL, token string) *GitHubSearch {
+	if baseURL == "" {
+		baseURL = DefaultGitHubURL
+	}
+	return &GitHubSearch{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *GitHubSearch) SearchRepositories(ctx context.Context, query, language string, limit int) ([]Repository, error) {
+	q := query
+	if language != "" {
+		// Quoted so that names like "Visual Basic" stay one qualifier.
+		q += ` language:"` + strings.ToLower(language) + `"`
+	}
+	params := url.Values{
+		"q":        {q},
+		"sort":     {"stars"},
+		"order":    {"desc"},
+		"per_page": {fmt.Sprint(limit)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.BaseURL+"/search/repositories?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if body.Message == "" {
+			body.Message = resp.Status
+		}
+		return nil, fmt.Errorf("GitHub search: %s", body.Message)
+	}
+
+	var body struct {
+		Items []struct {
+			FullName    string    `json:"full_name"`
+			Description string    `json:"description"`
+			HTMLURL     string    `json:"html_url"`
+			Stars       int       `json:"stargazers_count"`
+			Language    string    `json:"language"`
+			PushedAt    time.Time `json:"pushed_at"`
+			License     *struct {
+				SPDXID string `json:"spdx_id"`
+			} `json:"license"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("GitHub search: %w", err)
+	}
+
+	var repos []Repository
+	for _, item := range body.Items {
+		repo := Repository{
+			FullName:    item.FullName,
+			Description: item.Description,
+			URL:         item.HTMLURL,
+			Stars:       item.Stars,
+			Language:    item.Language,
+			PushedAt:    item.PushedAt,
+		}
+		if item.License != nil && item.License.SPDXID != "NOASSERTION" {
+			repo.License = item.License.SPDXID
+		}
+		repos = append(repos, repo)
+	}
+	if len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, nil
+}
+
+// Search providers for consult.search.provider.
+const (
+	SearchOffline = "offline"
+	SearchGitHub  = "github"
+)
+
+var (
+	searchMu       sync.Mutex
+	searchProvider SearchProvider
+)
+
+// SetSearchProvider replaces the provider consult searches with, overriding
+// the configuration. nil restores the configured provider.
+func SetSearchProvider(p SearchProvider) {
+	searchMu.Lock()
+	defer searchMu.Unlock()
+	searchProvider = p
+}
+
+// currentSearchProvider returns the provider set with SetSearchProvider, or
+// the one cfg configures.
+func currentSearchProvider(cfg SearchConfig) (SearchProvider, error) {
+	searchMu.Lock()
+	p := searchProvider
+	searchMu.Unlock()
+	if p != nil {
+		return p, nil
+	}
+	return configuredSearchProvider(cfg)
+}
+
+// configuredSearchProvider returns the provider named by consult.search in
+// .mtb.yaml, or by MTB_SEARCH when the config does not set one. Search is
+// offline by default; GitHub is at the configured URL, or MTB_GITHUB_URL,
+// and authenticated with GITHUB_TOKEN.
+func configuredSearchProvider(cfg SearchConfig) (SearchProvider, error) {
+	provider := cfg.Provider
+	if provider == "" {
+		provider = os.Getenv("MTB_SEARCH")
+	}
+	switch provider {
+	case "", SearchOffline:
+		return OfflineSearch{}, nil
+	case SearchGitHub:
+		baseURL := cfg.URL
+		if baseURL == "" {
+			baseURL = os.Getenv("MTB_GITHUB_URL")
+		}
+		return NewGitHubSearch(baseURL, os.Getenv("GITHUB_TOKEN")), nil
+	}
+	return nil, fmt.Errorf("unknown search provider %q: use %s or %s", provider, SearchOffline, SearchGitHub)
+}
+
+// searchStopWords are dropped from problem descriptions before searching,
+// since repository search requires every remaining word to match.
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "app": true, "application": true, "build": true, "create": true,
+	"for": true, "from": true, "i": true, "implement": true, "in": true, "into": true, "is": true,
+	"it": true, "make": true, "my": true, "need": true, "new": true, "of": true, "on": true, "our": true,
+	"service": true, "simple": true, "some": true, "that": true, "the": true, "to": true, "tool": true,
+	"want": true, "we": true, "with": true, "write": true,
+}
+
+// maxSearchTerms keeps queries broad enough to return results.
+const maxSearchTerms = 4
+
+// searchQuery reduces a problem description to its significant words.
+func searchQuery(problem string) string {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(problem), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '-' || r == '+' || r == '#')
+	}) {
+		if searchStopWords[w] || len(terms) == maxSearchTerms {
+			continue
+		}
+		terms = append(terms, w)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const githubSearchResponse = `{
+  "total_count": 2,
+  "items": [
+    {
+      "full_name": "go-gitea/gitea",
+      "description": "Git with a cup of tea",
+      "html_url": "https://github.com/go-gitea/gitea",
+      "stargazers_count": 46000,
+      "language": "Go",
+      "pushed_at": "2026-10-01T12:00:00Z",
+      "license": {"spdx_id": "MIT"}
+    },
+    {
+      "full_name": "example/unlicensed",
+      "html_url": "https://github.com/example/unlicensed",
+      "stargazers_count": 12,
+      "language": "Go",
+      "pushed_at": "2021-01-01T00:00:00Z",
+      "license": null
+    }
+  ]
+}`
+
+func TestGitHubSearch(t *testing.T) {
+	var gotQuery, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/search/repositories" {
+			http.NotFound(w, r)
+			return
+		}
+		gotQuery = r.URL.Query().Get("q")
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(githubSearchResponse))
+	}))
+	defer srv.Close()
+
+	repos, err := NewGitHubSearch(srv.URL+"/api/v3/", "secret").SearchRepositories(context.Background(), "git hosting", "Go", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != `git hosting language:"go"` {
+		t.Errorf("unexpected query %q", gotQuery)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("unexpected authorization %q", gotAuth)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repositories, got %d", len(repos))
+	}
+	want := Repository{
+		FullName:    "go-gitea/gitea",
+		Description: "Git with a cup of tea",
+		URL:         "https://github.com/go-gitea/gitea",
+		Stars:       46000,
+		License:     "MIT",
+		Language:    "Go",
+		PushedAt:    time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if repos[0] != want {
+		t.Errorf("got %+v, want %+v", repos[0], want)
+	}
+	if repos[1].License != "" {
+		t.Errorf("expected no license, got %q", repos[1].License)
+	}
+}
+
+func TestGitHubSearch_MultiWordLanguage(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("q")
+		w.Write([]byte(githubSearchResponse))
+	}))
+	defer srv.Close()
+
+	if _, err := NewGitHubSearch(srv.URL, "").SearchRepositories(context.Background(), "spreadsheet", "Visual Basic", 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != `spreadsheet language:"visual basic"` {
+		t.Errorf("expected the language to be quoted, got %q", gotQuery)
+	}
+}
+
+func TestGitHubSearch_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewGitHubSearch(srv.URL, "").SearchRepositories(context.Background(), "wiki", "", 5)
+	if err == nil || !strings.Contains(err.Error(), "API rate limit exceeded") {
+		t.Fatalf("expected the API message in the error, got %v", err)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	tests := map[string]string{
+		"I want to build a customer survey tool":       "customer survey",
+		"Implement a feature flag service for our app": "feature flag",
+		"parse JSON": "parse json",
+		"a an the":   "",
+		"store metrics in a time series database with retention": "store metrics time series",
+	}
+	for problem, want := range tests {
+		if got := searchQuery(problem); got != want {
+			t.Errorf("searchQuery(%q) = %q, want %q", problem, got, want)
+		}
+	}
+}
+
+func TestHandleConsult_Repositories(t *testing.T) {
+	// consult looks for .mtb.yaml from the working directory.
+	t.Chdir(t.TempDir())
+
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("q")
+		w.Write([]byte(githubSearchResponse))
+	}))
+	defer srv.Close()
+	SetSearchProvider(NewGitHubSearch(srv.URL, ""))
+	t.Cleanup(func() { SetSearchProvider(OfflineSearch{}) })
+
+	_, output, err := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{
+		Problem:  "self-hosted git server",
+		Language: "Go",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != `self-hosted git server language:"go"` {
+		t.Errorf("unexpected query %q", gotQuery)
+	}
+	if len(output.Repositories) != 2 || output.Repositories[0].FullName != "go-gitea/gitea" {
+		t.Fatalf("unexpected repositories: %+v", output.Repositories)
+	}
+	if !strings.Contains(output.Guidance, "matching repositories") || !strings.Contains(output.Guidance, "were sent to "+srv.URL) {
+		t.Errorf("expected guidance to mention the repositories and where the query went, got %q", output.Guidance)
+	}
+}
+
+func TestConfiguredSearchProvider(t *testing.T) {
+	t.Setenv("MTB_SEARCH", "")
+	t.Setenv("MTB_GITHUB_URL", "https://env.example.com/api/v3")
+
+	if p, err := configuredSearchProvider(SearchConfig{}); err != nil || p != (OfflineSearch{}) {
+		t.Errorf("expected offline search by default, got %v, %v", p, err)
+	}
+	p, err := configuredSearchProvider(SearchConfig{Provider: SearchGitHub, URL: "https://github.example.com/api/v3/"})
+	if gh, ok := p.(*GitHubSearch); err != nil || !ok || gh.BaseURL != "https://github.example.com/api/v3" {
+		t.Errorf("expected GitHub at the configured URL, got %+v, %v", p, err)
+	}
+	t.Setenv("MTB_SEARCH", SearchGitHub)
+	p, err = configuredSearchProvider(SearchConfig{})
+	if gh, ok := p.(*GitHubSearch); err != nil || !ok || gh.BaseURL != "https://env.example.com/api/v3" {
+		t.Errorf("expected GitHub from the environment, got %+v, %v", p, err)
+	}
+	if p, err := configuredSearchProvider(SearchConfig{Provider: SearchOffline}); err != nil || p != (OfflineSearch{}) {
+		t.Errorf("expected the config to win over MTB_SEARCH, got %v, %v", p, err)
+	}
+	if _, err := configuredSearchProvider(SearchConfig{Provider: "gitlab"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestHandleConsult_SearchFailure(t *testing.T) {
+	// consult looks for .mtb.yaml from the working directory.
+	t.Chdir(t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	SetSearchProvider(NewGitHubSearch(srv.URL, ""))
+	t.Cleanup(func() { SetSearchProvider(OfflineSearch{}) })
+
+	result, output, err := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{Problem: "self-hosted git server"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatal("expected a failed search not to fail the consultation")
+	}
+	if len(output.Questions) != 7 || !strings.Contains(output.Guidance, "Repository search failed") {
+		t.Fatalf("expected questions and a search failure note, got %q", output.Guidance)
+	}
+}
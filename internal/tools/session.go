@@ -0,0 +1,528 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// sessionDir is where consult sessions live, relative to the state root.
+const sessionDir = ".mtb/sessions"
+
+// draftSessionAge is how long a session nobody answered is kept in the user
+// cache before it is pruned.
+const draftSessionAge = 30 * 24 * time.Hour
+
+// maxWhys is the depth of the "why" chain that starts at the root-cause
+// question.
+const maxWhys = 5
+
+// Session resolutions.
+const (
+	ResolutionBuild   = "build"
+	ResolutionAdopt   = "buy/adopt"
+	ResolutionAbandon = "abandon"
+)
+
+// Question kinds. Built-in questions are tagged by position so follow-ups
+// can be generated from their answers.
+const (
+	kindRootCause     = "root-cause"
+	kindWhy           = "why"
+	kindAlternatives  = "alternatives"
+	kindDependencies  = "dependencies"
+	kindRightProblem  = "right-problem"
+	kindSecondOpinion = "second-opinion"
+	kindMaintenance   = "maintenance"
+	kindOwnership     = "ownership"
+	kindFollowUp      = "follow-up"
+	kindCustom        = "custom"
+)
+
+// builtinKinds tags the questions from buildQuestions, in order.
+var builtinKinds = []string{
+	kindRootCause,
+	kindAlternatives,
+	kindDependencies,
+	kindRightProblem,
+	kindSecondOpinion,
+	kindMaintenance,
+	kindOwnership,
+}
+
+// sessionID matches the IDs newSessionID generates.
+var sessionID = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// sessionMu serializes read-modify-write cycles on session files.
+var sessionMu sync.Mutex
+
+// SessionQuestion is a question in a consult session and its answer.
+type SessionQuestion struct {
+	ID       int    `json:"id"`
+	Question string `json:"question"`
+	Kind     string `json:"kind"`
+	// Parent is the ID of the question whose answer prompted this
+	// follow-up, or zero.
+	Parent int `json:"parent,omitempty"`
+	// Depth is the question's position in the "why" chain, starting at 1
+	// for the root-cause question.
+	Depth      int        `json:"depth,omitempty"`
+	Answer     string     `json:"answer,omitempty"`
+	AnsweredAt *time.Time `json:"answeredAt,omitempty"`
+}
+
+// ConsultSession is a consultation persisted across conversations.
+type ConsultSession struct {
+	ID        string            `json:"id"`
+	Problem   string            `json:"problem"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Questions []SessionQuestion `json:"questions"`
+	// Alternatives and Repositories are the existing solutions consult
+	// found, kept so the decision record can list them.
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+	Repositories []Repository  `json:"repositories,omitempty"`
+	Resolution   string        `json:"resolution,omitempty"`
+	Rationale    string        `json:"rationale,omitempty"`
+}
+
+// Pending returns the questions that have not been answered.
+func (s *ConsultSession) Pending() []SessionQuestion {
+	var pending []SessionQuestion
+	for _, q := range s.Questions {
+		if q.AnsweredAt == nil {
+			pending = append(pending, q)
+		}
+	}
+	return pending
+}
+
+// SessionSummary describes a stored session without its questions.
+type SessionSummary struct {
+	ID         string    `json:"id"`
+	Problem    string    `json:"problem"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Pending    int       `json:"pending"`
+	Resolution string    `json:"resolution,omitempty"`
+}
+
+type ConsultAnswerInput struct {
+	SessionID  string `json:"session_id,omitempty" jsonschema:"session ID returned by consult; omit to list saved sessions"`
+	Path       string `json:"path,omitempty" jsonschema:"project directory the session was started in (default .)"`
+	QuestionID int    `json:"question_id,omitempty" jsonschema:"ID of the question being answered"`
+	Answer     string `json:"answer,omitempty" jsonschema:"the user's answer, in their words"`
+	Resolution string `json:"resolution,omitempty" jsonschema:"the user's decision: build, buy/adopt, or abandon"`
+	Rationale  string `json:"rationale,omitempty" jsonschema:"why the user chose the resolution"`
+}
+
+type ConsultAnswerOutput struct {
+	Session *ConsultSession `json:"session,omitempty"`
+	// FollowUps are the questions this answer added.
+	FollowUps []SessionQuestion `json:"followUps,omitempty"`
+	Pending   []SessionQuestion `json:"pending,omitempty"`
+	Sessions  []SessionSummary  `json:"sessions,omitempty"`
+	Guidance  string            `json:"guidance"`
+}
+
+// newConsultSession returns an unsaved session for problem with the given
+// questions and the existing solutions found for it.
+func newConsultSession(problem string, questions []string, alternatives []Alternative, repos []Repository) (*ConsultSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	s := &ConsultSession{
+		ID:           id,
+		Problem:      problem,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Alternatives: alternatives,
+		Repositories: repos,
+	}
+
+	builtin := buildQuestions(problem)
+	for i, q := range questions {
+		kind := kindCustom
+		if i < len(builtin) && q == builtin[i] {
+			kind = builtinKinds[i]
+		}
+		sq := SessionQuestion{ID: i + 1, Question: q, Kind: kind}
+		if kind == kindRootCause {
+			sq.Depth = 1
+		}
+		s.Questions = append(s.Questions, sq)
+	}
+	return s, nil
+}
+
+// storeSession saves s under the state root for path.
+func storeSession(ctx context.Context, path string, s *ConsultSession) error {
+	root := sessionRoot(ctx, path)
+	// Never create the project directory itself, e.g. for a path that
+	// does not exist yet.
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return saveSession(root, s)
+}
+
+func HandleConsultAnswer(ctx context.Context, req *mcp.CallToolRequest, input ConsultAnswerInput) (*mcp.CallToolResult, ConsultAnswerOutput, error) {
+	root := sessionRoot(ctx, input.Path)
+
+	if input.SessionID == "" {
+		sessions, err := listSessions(root)
+		if err != nil {
+			return ErrResult[ConsultAnswerOutput]("listing sessions: " + err.Error())
+		}
+		output := ConsultAnswerOutput{
+			Sessions: sessions,
+			Guidance: "Ask the user which consultation to resume, then call consult_answer with its session_id.",
+		}
+		if len(sessions) == 0 {
+			output.Guidance = "No consult sessions are saved. Call consult to start one."
+		}
+		return nil, output, nil
+	}
+
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	s, err := loadSession(root, input.SessionID)
+	if err != nil {
+		return ErrResult[ConsultAnswerOutput](err.Error())
+	}
+
+	var output ConsultAnswerOutput
+	changed := false
+	if input.QuestionID != 0 || input.Answer != "" {
+		followUps, err := s.answer(input.QuestionID, input.Answer)
+		if err != nil {
+			return ErrResult[ConsultAnswerOutput](err.Error())
+		}
+		output.FollowUps = followUps
+		changed = true
+	}
+	if input.Resolution != "" {
+		resolution, ok := parseResolution(input.Resolution)
+		if !ok {
+			return ErrResult[ConsultAnswerOutput](fmt.Sprintf("unknown resolution %q: use build, buy/adopt, or abandon", input.Resolution))
+		}
+		s.Resolution, s.Rationale = resolution, input.Rationale
+		changed = true
+	}
+	if changed {
+		s.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+		if err := saveSession(root, s); err != nil {
+			return ErrResult[ConsultAnswerOutput]("saving session: " + err.Error())
+		}
+	}
+
+	output.Session = s
+	output.Pending = s.Pending()
+	output.Guidance = sessionGuidance(s, output.FollowUps, output.Pending)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: sessionSummary(s, output.FollowUps, output.Pending)}},
+	}, output, nil
+}
+
+// answer records an answer and appends the follow-up questions it
+// prompts. Re-answering a question replaces its answer without adding more
+// follow-ups.
+func (s *ConsultSession) answer(id int, answer string) ([]SessionQuestion, error) {
+	answer = strings.TrimSpace(answer)
+	if id == 0 || answer == "" {
+		return nil, errors.New("question_id and answer are required to record an answer")
+	}
+	i := slices.IndexFunc(s.Questions, func(q SessionQuestion) bool { return q.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("session %s has no question %d", s.ID, id)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	q := &s.Questions[i]
+	reanswered := q.AnsweredAt != nil
+	q.Answer, q.AnsweredAt = answer, &now
+	if reanswered {
+		return nil, nil
+	}
+
+	var added []SessionQuestion
+	for _, f := range followUps(*q) {
+		f.ID = len(s.Questions) + 1
+		f.Parent = q.ID
+		s.Questions = append(s.Questions, f)
+		added = append(added, f)
+	}
+	return added, nil
+}
+
+// followUps generates the questions an answer prompts. Root-cause answers
+// start a chain of "why" questions up to maxWhys deep; other built-in
+// questions are probed when the answer dodges or undercuts them.
+func followUps(q SessionQuestion) []SessionQuestion {
+	a := strings.ToLower(q.Answer)
+	ask := func(text string) []SessionQuestion {
+		return []SessionQuestion{{Question: text, Kind: kindFollowUp}}
+	}
+
+	switch q.Kind {
+	case kindRootCause, kindWhy:
+		if q.Depth >= maxWhys || isUnsure(a) {
+			return nil
+		}
+		return []SessionQuestion{{
+			Question: fmt.Sprintf("Why? You said %q. What is the reason behind that, and what happens if it is never addressed?", clip(q.Answer, 160)),
+			Kind:     kindWhy,
+			Depth:    q.Depth + 1,
+		}}
+	case kindAlternatives:
+		if isNegative(a) || strings.Contains(a, "didn't search") || strings.Contains(a, "haven't looked") {
+			return ask("Before going further, spend a few minutes searching for existing solutions. What did you find, and what would it take to adopt the closest one?")
+		}
+		if reason := after(a, "because"); reason != "" {
+			return ask(fmt.Sprintf("Would working around %q be cheaper than building and maintaining your own version? Could you contribute the missing piece upstream instead?", clip(reason, 120)))
+		}
+	case kindDependencies:
+		if isAffirmative(a) {
+			return ask("Which dependency could handle it, and what would it take to use it here instead of writing new code?")
+		}
+	case kindRightProblem:
+		if isNegative(a) || isUnsure(a) {
+			return ask("What would you need to find out to be sure, and who could tell you?")
+		}
+	case kindSecondOpinion:
+		if isNegative(a) {
+			return ask("Who could review the problem and your proposed solution before you start, and when can you talk to them?")
+		}
+	case kindMaintenance:
+		if isUnsure(a) {
+			return ask("Estimate it: how many hours a month will this need once it ships, and what will those hours not be spent on?")
+		}
+	case kindOwnership:
+		if isUnsure(a) || isNegative(a) || strings.Contains(a, "nobody") || strings.Contains(a, "no one") {
+			return ask("Without a clear owner this is likely to rot. Who will own it long-term, and should it be built if nobody will?")
+		}
+	}
+	return nil
+}
+
+var (
+	negativeAnswer    = regexp.MustCompile(`^(no|nope|not|none|nothing|never|haven't|have not|didn't|did not)\b`)
+	affirmativeAnswer = regexp.MustCompile(`^(yes|yeah|yep|maybe|possibly|probably|perhaps|i think so)\b`)
+	unsureAnswer      = regexp.MustCompile(`not sure|don't know|do not know|unsure|no idea|unknown|not certain|\bidk\b`)
+)
+
+func isNegative(a string) bool    { return negativeAnswer.MatchString(a) }
+func isAffirmative(a string) bool { return affirmativeAnswer.MatchString(a) }
+func isUnsure(a string) bool      { return unsureAnswer.MatchString(a) }
+
+// after returns the trimmed text following the first occurrence of word.
+func after(s, word string) string {
+	_, rest, ok := strings.Cut(s, word)
+	if !ok {
+		return ""
+	}
+	return strings.Trim(rest, " .,;:!")
+}
+
+// clip shortens s to at most n runes for quoting in a question.
+func clip(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= n {
+		return string(r)
+	}
+	return strings.TrimSpace(string(r[:n])) + "…"
+}
+
+func parseResolution(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "build":
+		return ResolutionBuild, true
+	case "buy/adopt", "buy", "adopt":
+		return ResolutionAdopt, true
+	case "abandon":
+		return ResolutionAbandon, true
+	}
+	return "", false
+}
+
+func sessionGuidance(s *ConsultSession, followUps, pending []SessionQuestion) string {
+	switch s.Resolution {
+	case ResolutionBuild:
+		return "The user decided to build. Before writing code, save a snapshot so the complexity added by this work can be compared afterwards."
+	case ResolutionAdopt:
+		return "The user decided to buy or adopt an existing solution. Help them evaluate and integrate it; do NOT build the feature from scratch."
+	case ResolutionAbandon:
+		return "The user decided not to pursue this. Do NOT write code for it."
+	}
+	var g strings.Builder
+	if len(followUps) > 0 {
+		g.WriteString("IMPORTANT: The answer raised follow-up questions. Ask them before moving on. ")
+	}
+	if len(pending) > 0 {
+		g.WriteString("Present the pending questions to the user one at a time and record each answer with consult_answer. Do NOT answer them yourself.")
+	} else {
+		g.WriteString("Every question has been answered. Summarize the answers for the user and ask them to decide: build, buy/adopt, or abandon. " +
+			"Record the decision with consult_answer using resolution and rationale.")
+	}
+	return g.String()
+}
+
+func sessionSummary(s *ConsultSession, followUps, pending []SessionQuestion) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Consult session %s for %q", s.ID, s.Problem)
+	if s.Resolution != "" {
+		fmt.Fprintf(&b, " resolved: %s", s.Resolution)
+	}
+	b.WriteString(".\n")
+	for _, q := range followUps {
+		fmt.Fprintf(&b, "Follow-up %d: %s\n", q.ID, q.Question)
+	}
+	fmt.Fprintf(&b, "%d of %d questions pending.", len(pending), len(s.Questions))
+	return b.String()
+}
+
+// sessionRoot resolves the state root for a session path.
+func sessionRoot(ctx context.Context, path string) string {
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	return stateRoot(ctx, absPath)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func sessionFile(root, id string) string {
+	return filepath.Join(root, filepath.FromSlash(sessionDir), id+".json")
+}
+
+// draftSessionFile is where a session lives until its first answer, in the
+// user cache rather than the repository, so that asking questions nobody
+// answers leaves no files behind.
+func draftSessionFile(id string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "mtb", "sessions", id+".json")
+}
+
+// saveSession writes s to the repository once it has an answer or a
+// resolution, and to the user cache until then.
+func saveSession(root string, s *ConsultSession) error {
+	draft := s.Resolution == "" && len(s.Pending()) == len(s.Questions)
+	file := sessionFile(root, s.ID)
+	if draft {
+		file = draftSessionFile(s.ID)
+		pruneDraftSessions(filepath.Dir(file))
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if !draft {
+		os.Remove(draftSessionFile(s.ID))
+	}
+	return nil
+}
+
+// pruneDraftSessions removes drafts older than draftSessionAge.
+func pruneDraftSessions(dir string) {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > draftSessionAge {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+func loadSession(root, id string) (*ConsultSession, error) {
+	if !sessionID.MatchString(id) {
+		return nil, fmt.Errorf("invalid session ID %q", id)
+	}
+	data, err := os.ReadFile(sessionFile(root, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = os.ReadFile(draftSessionFile(id))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("session %s not found; call consult_answer without a session_id to list sessions", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s ConsultSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid session %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// listSessions returns the sessions saved in the repository, most recently
+// updated first. Sessions nobody answered yet are not listed.
+func listSessions(root string) ([]SessionSummary, error) {
+	dir := filepath.Join(root, filepath.FromSlash(sessionDir))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []SessionSummary
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !sessionID.MatchString(id) {
+			continue
+		}
+		s, err := loadSession(root, id)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, SessionSummary{
+			ID:         s.ID,
+			Problem:    s.Problem,
+			UpdatedAt:  s.UpdatedAt,
+			Pending:    len(s.Pending()),
+			Resolution: s.Resolution,
+		})
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt) })
+	return sessions, nil
+}
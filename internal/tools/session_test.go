@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func startSession(t *testing.T, dir string) string {
+	t.Helper()
+	_, output, err := HandleConsult(context.Background(), &mcp.CallToolRequest{}, ConsultInput{Problem: "a job queue", Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.SessionID == "" {
+		t.Fatal("expected a session ID")
+	}
+	return output.SessionID
+}
+
+func answer(t *testing.T, input ConsultAnswerInput) ConsultAnswerOutput {
+	t.Helper()
+	result, output, err := HandleConsultAnswer(context.Background(), &mcp.CallToolRequest{}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil && result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
+	return output
+}
+
+func TestConsultSession_Persisted(t *testing.T) {
+	dir := t.TempDir()
+	id := startSession(t, dir)
+
+	// Until the first answer the session stays out of the repository.
+	if _, err := os.Stat(filepath.Join(dir, ".mtb")); err == nil {
+		t.Fatal("expected no .mtb directory before the first answer")
+	}
+
+	// A later conversation resumes it by ID.
+	output := answer(t, ConsultAnswerInput{SessionID: id, Path: dir})
+	if output.Session.Problem != "a job queue" || len(output.Pending) != 7 {
+		t.Fatalf("unexpected resumed session: %+v", output.Session)
+	}
+
+	answer(t, ConsultAnswerInput{SessionID: id, Path: dir, QuestionID: 2, Answer: "We looked at River."})
+	if _, err := os.Stat(filepath.Join(dir, ".mtb", "sessions", id+".json")); err != nil {
+		t.Fatalf("expected the session on disk after an answer: %v", err)
+	}
+	if _, err := os.Stat(draftSessionFile(id)); err == nil {
+		t.Error("expected the draft to be removed once the session was saved")
+	}
+
+	output = answer(t, ConsultAnswerInput{Path: dir})
+	if len(output.Sessions) != 1 || output.Sessions[0].ID != id || output.Sessions[0].Pending != 6 {
+		t.Fatalf("expected the session in the list, got %+v", output.Sessions)
+	}
+}
+
+func TestConsultSession_WhyChain(t *testing.T) {
+	dir := t.TempDir()
+	id := startSession(t, dir)
+
+	answers := []string{
+		"Background jobs are dropped when the web process restarts.",
+		"Jobs run in goroutines inside the request handler.",
+		"We never had a queue and it was quick to write.",
+		"The first version had a deadline.",
+		"A customer demo was scheduled.",
+	}
+	questionID := 1
+	for i, a := range answers {
+		output := answer(t, ConsultAnswerInput{SessionID: id, Path: dir, QuestionID: questionID, Answer: a})
+		if i == len(answers)-1 {
+			if len(output.FollowUps) != 0 {
+				t.Fatalf("expected the chain to stop after %d whys, got %+v", maxWhys, output.FollowUps)
+			}
+			break
+		}
+		if len(output.FollowUps) != 1 {
+			t.Fatalf("answer %d: expected one follow-up, got %+v", i+1, output.FollowUps)
+		}
+		f := output.FollowUps[0]
+		if f.Kind != kindWhy || f.Depth != i+2 || f.Parent != questionID || !strings.Contains(f.Question, a) {
+			t.Fatalf("answer %d: unexpected follow-up %+v", i+1, f)
+		}
+		questionID = f.ID
+	}
+}
+
+func TestConsultSession_FollowUps(t *testing.T) {
+	tests := []struct {
+		kind, answer string
+		want         string
+	}{
+		{kindAlternatives, "We tried Sidekiq but rejected it because it needs Redis.", "it needs redis"},
+		{kindAlternatives, "No, I didn't search.", "searching for existing solutions"},
+		{kindDependencies, "Yes, maybe river could.", "Which dependency"},
+		{kindRightProblem, "Not sure.", "find out"},
+		{kindSecondOpinion, "No.", "Who could review"},
+		{kindMaintenance, "I don't know.", "hours a month"},
+		{kindOwnership, "Nobody yet.", "owner"},
+		{kindRootCause, "I don't know.", ""},
+		{kindDependencies, "No.", ""},
+		{kindCustom, "No.", ""},
+	}
+	for _, tt := range tests {
+		got := followUps(SessionQuestion{Kind: tt.kind, Answer: tt.answer, Depth: 1})
+		switch {
+		case tt.want == "" && len(got) != 0:
+			t.Errorf("%s %q: expected no follow-up, got %q", tt.kind, tt.answer, got[0].Question)
+		case tt.want != "" && (len(got) != 1 || !strings.Contains(got[0].Question, tt.want)):
+			t.Errorf("%s %q: expected a follow-up containing %q, got %+v", tt.kind, tt.answer, tt.want, got)
+		}
+	}
+}
+
+func TestConsultSession_Resolution(t *testing.T) {
+	dir := t.TempDir()
+	id := startSession(t, dir)
+
+	// Re-answering replaces the answer without another follow-up.
+	answer(t, ConsultAnswerInput{SessionID: id, Path: dir, QuestionID: 5, Answer: "No."})
+	output := answer(t, ConsultAnswerInput{SessionID: id, Path: dir, QuestionID: 5, Answer: "Yes, with Sam."})
+	if len(output.FollowUps) != 0 || len(output.Session.Questions) != 8 || output.Session.Questions[4].Answer != "Yes, with Sam." {
+		t.Fatalf("unexpected session after re-answering: %+v", output.Session.Questions)
+	}
+
+	output = answer(t, ConsultAnswerInput{SessionID: id, Path: dir, Resolution: "adopt", Rationale: "River covers it."})
+	if output.Session.Resolution != ResolutionAdopt || output.Session.Rationale != "River covers it." {
+		t.Fatalf("unexpected resolution: %+v", output.Session)
+	}
+	if !strings.Contains(output.Guidance, "do NOT build") {
+		t.Errorf("unexpected guidance: %q", output.Guidance)
+	}
+
+	result, _, _ := HandleConsultAnswer(context.Background(), &mcp.CallToolRequest{}, ConsultAnswerInput{SessionID: id, Path: dir, Resolution: "rewrite"})
+	if !result.IsError {
+		t.Fatal("expected an error for an unknown resolution")
+	}
+}
+
+func TestConsultSession_Errors(t *testing.T) {
+	dir := t.TempDir()
+	id := startSession(t, dir)
+
+	for _, input := range []ConsultAnswerInput{
+		{SessionID: "../../etc/passwd", Path: dir},
+		{SessionID: "0123456789ab", Path: dir},
+		{SessionID: id, Path: dir, QuestionID: 99, Answer: "x"},
+		{SessionID: id, Path: dir, QuestionID: 1},
+	} {
+		result, _, _ := HandleConsultAnswer(context.Background(), &mcp.CallToolRequest{}, input)
+		if !result.IsError {
+			t.Errorf("expected an error for %+v", input)
+		}
+	}
+}
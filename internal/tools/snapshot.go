@@ -0,0 +1,304 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// snapshotDir is where snapshots live, relative to the repository root (or
+// the analyzed directory outside a git repository).
+const snapshotDir = ".mtb/snapshots"
+
+// snapshotName keeps names usable as file names on every platform.
+var snapshotName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type SnapshotInput struct {
+	Action            string   `json:"action,omitempty" jsonschema:"save (default), list, or delete"`
+	Name              string   `json:"name,omitempty" jsonschema:"snapshot name, e.g. before-auth-refactor (required for save and delete)"`
+	Path              string   `json:"path,omitempty" jsonschema:"directory to analyze (default .)"`
+	ExcludeDir        []string `json:"exclude_dir,omitempty" jsonschema:"directories to exclude from analysis"`
+	ExcludeExtensions []string `json:"exclude_ext,omitempty" jsonschema:"file extensions to exclude (e.g. min.js)"`
+	IncludeExtensions []string `json:"include_ext,omitempty" jsonschema:"only include these file extensions"`
+}
+
+// SnapshotFilters are the scc filters a snapshot was taken with. Diffs
+// against the snapshot reuse them unless told otherwise, so both sides
+// count the same files.
+type SnapshotFilters struct {
+	ExcludeDir        []string `json:"exclude_dir,omitempty"`
+	ExcludeExtensions []string `json:"exclude_ext,omitempty"`
+	IncludeExtensions []string `json:"include_ext,omitempty"`
+}
+
+// Snapshot is a stored stats result.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Commit is the HEAD commit when the snapshot was taken, or empty
+	// outside a git repository.
+	Commit string `json:"commit,omitempty"`
+	// Path is the analyzed directory relative to the snapshot store's root.
+	Path    string          `json:"path"`
+	Filters SnapshotFilters `json:"filters"`
+	Stats   StatsOutput     `json:"stats"`
+}
+
+// SnapshotSummary describes a stored snapshot without its stats.
+type SnapshotSummary struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Commit    string    `json:"commit,omitempty"`
+	Path      string    `json:"path"`
+	Code      int64     `json:"code"`
+	// Complexity is the snapshot's total complexity across languages.
+	Complexity int64 `json:"complexity"`
+}
+
+type SnapshotOutput struct {
+	Snapshot  *Snapshot         `json:"snapshot,omitempty"`
+	Snapshots []SnapshotSummary `json:"snapshots,omitempty"`
+	Deleted   string            `json:"deleted,omitempty"`
+	Guidance  string            `json:"guidance"`
+}
+
+func HandleSnapshot(ctx context.Context, req *mcp.CallToolRequest, input SnapshotInput) (*mcp.CallToolResult, SnapshotOutput, error) {
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[SnapshotOutput]("invalid path: " + err.Error())
+	}
+	root := stateRoot(ctx, absPath)
+
+	switch input.Action {
+	case "", "save":
+		if err := checkSnapshotName(input.Name); err != nil {
+			return ErrResult[SnapshotOutput](err.Error())
+		}
+		cfg, err := LoadConfig(absPath)
+		if err != nil {
+			return ErrResult[SnapshotOutput](err.Error())
+		}
+		filters := SnapshotFilters{
+			ExcludeDir:        orDefault(input.ExcludeDir, cfg.Stats.ExcludeDir),
+			ExcludeExtensions: orDefault(input.ExcludeExtensions, cfg.Stats.ExcludeExtensions),
+			IncludeExtensions: orDefault(input.IncludeExtensions, cfg.Stats.IncludeExtensions),
+		}
+		model, err := resolveCocomo(CocomoModel{}, cfg.Cocomo)
+		if err != nil {
+			return ErrResult[SnapshotOutput](err.Error())
+		}
+		stats, err := RunSCC(ctx, absPath, &model, true, filters.ExcludeDir, filters.ExcludeExtensions, filters.IncludeExtensions)
+		if err != nil {
+			return ErrResult[SnapshotOutput]("analysis failed: " + err.Error())
+		}
+		stats.Files = nil
+
+		snap := &Snapshot{
+			Name:      input.Name,
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+			Path:      storePath(root, absPath),
+			Filters:   filters,
+			Stats:     *stats,
+		}
+		if commit, err := runGit(ctx, absPath, "rev-parse", "HEAD"); err == nil {
+			snap.Commit = commit
+		}
+		if err := saveSnapshot(root, snap); err != nil {
+			return ErrResult[SnapshotOutput]("saving snapshot: " + err.Error())
+		}
+		publishSnapshot(ctx, root, absPath, snap.Name)
+
+		output := SnapshotOutput{
+			Snapshot: snap,
+			Guidance: fmt.Sprintf("Snapshot %q saved. After making changes, call compare with base_snapshot=%q "+
+				"(or stats with baseline=%q) to show the user how complexity changed since this point.", snap.Name, snap.Name, snap.Name),
+		}
+		summary := fmt.Sprintf("Saved snapshot %q of %s: %d lines of code, complexity %d.",
+			snap.Name, snap.Path, totalCode(snap.Stats), totalComplexity(snap.Stats))
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+		}, output, nil
+
+	case "list":
+		snaps, err := listSnapshots(root)
+		if err != nil {
+			return ErrResult[SnapshotOutput]("listing snapshots: " + err.Error())
+		}
+		output := SnapshotOutput{Snapshots: snaps, Guidance: "Use a snapshot name as base_snapshot in compare or baseline in stats."}
+		if len(snaps) == 0 {
+			output.Guidance = "No snapshots saved yet. Save one with action=save before making changes."
+		}
+		return nil, output, nil
+
+	case "delete":
+		if err := checkSnapshotName(input.Name); err != nil {
+			return ErrResult[SnapshotOutput](err.Error())
+		}
+		if err := deleteSnapshot(root, input.Name); err != nil {
+			return ErrResult[SnapshotOutput](err.Error())
+		}
+		publishSnapshot(ctx, root, absPath, input.Name)
+		return nil, SnapshotOutput{Deleted: input.Name, Guidance: fmt.Sprintf("Snapshot %q deleted.", input.Name)}, nil
+	}
+
+	return ErrResult[SnapshotOutput](fmt.Sprintf("unknown action %q: use save, list, or delete", input.Action))
+}
+
+// stateRoot returns the directory whose .mtb holds mtb's saved state, such
+// as snapshots, for absPath: the enclosing git repository, so state is
+// found from anywhere in it, or absPath itself.
+func stateRoot(ctx context.Context, absPath string) string {
+	dir := absPath
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if top, err := runGit(ctx, dir, "rev-parse", "--show-toplevel"); err == nil {
+		return filepath.FromSlash(top)
+	}
+	return dir
+}
+
+// storePath returns absPath relative to the store root, "." for the root
+// itself.
+func storePath(root, absPath string) string {
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return filepath.ToSlash(absPath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func checkSnapshotName(name string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if !snapshotName.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+func snapshotFile(root, name string) string {
+	return filepath.Join(root, filepath.FromSlash(snapshotDir), name+".json")
+}
+
+func saveSnapshot(root string, snap *Snapshot) error {
+	file := snapshotFile(root, snap.Name)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// loadSnapshot reads the named snapshot from the store for absPath.
+func loadSnapshot(ctx context.Context, absPath, name string) (*Snapshot, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(snapshotFile(stateRoot(ctx, absPath), name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot %q not found; list snapshots with the snapshot tool", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %q: %w", name, err)
+	}
+	return &snap, nil
+}
+
+// listSnapshots returns the stored snapshots, newest first.
+func listSnapshots(root string) ([]SnapshotSummary, error) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(snapshotDir)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snaps []SnapshotSummary
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(snapshotDir), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			continue
+		}
+		snaps = append(snaps, SnapshotSummary{
+			Name:       name,
+			CreatedAt:  snap.CreatedAt,
+			Commit:     snap.Commit,
+			Path:       snap.Path,
+			Code:       totalCode(snap.Stats),
+			Complexity: totalComplexity(snap.Stats),
+		})
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].CreatedAt.After(snaps[j].CreatedAt) })
+	return snaps, nil
+}
+
+func deleteSnapshot(root, name string) error {
+	err := os.Remove(snapshotFile(root, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("snapshot %q not found", name)
+	}
+	return err
+}
+
+// baselineWarnings flags a diff whose current side covers a different
+// directory than the snapshot did, or whose costs use a different COCOMO
+// model.
+func baselineWarnings(ctx context.Context, snap *Snapshot, absPath string, model CocomoModel) []string {
+	var warnings []string
+	if path := storePath(stateRoot(ctx, absPath), absPath); path != snap.Path {
+		warnings = append(warnings, fmt.Sprintf("snapshot %q was taken of %s but %s was analyzed", snap.Name, snap.Path, path))
+	}
+	if m := snap.Stats.Cocomo; m != nil && *m != model {
+		warnings = append(warnings, fmt.Sprintf("snapshot %q was estimated with %s but the current side with %s, so the cost delta mixes models", snap.Name, m, model))
+	}
+	return warnings
+}
+
+func totalCode(s StatsOutput) int64 {
+	var n int64
+	for _, l := range s.LanguageSummary {
+		n += l.Code
+	}
+	return n
+}
+
+func totalComplexity(s StatsOutput) int64 {
+	var n int64
+	for _, l := range s.LanguageSummary {
+		n += l.Complexity
+	}
+	return n
+}
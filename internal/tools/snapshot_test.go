@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func saveTestSnapshot(t *testing.T, dir, name string) *Snapshot {
+	t.Helper()
+	result, output, err := HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Name: name, Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
+	return output.Snapshot
+}
+
+func TestHandleSnapshot_SaveInGitRepo(t *testing.T) {
+	dir := initGitRepo(t, map[string]string{"svc/main.go": "package main\n\nfunc main() {}\n"})
+	head := gitT(t, dir, "rev-parse", "HEAD")
+
+	snap := saveTestSnapshot(t, filepath.Join(dir, "svc"), "before")
+	if snap.Commit != head {
+		t.Errorf("expected commit %s, got %s", head, snap.Commit)
+	}
+	if snap.Path != "svc" {
+		t.Errorf("expected path relative to the repository root, got %q", snap.Path)
+	}
+	if snap.CreatedAt.IsZero() || len(snap.Stats.LanguageSummary) != 1 {
+		t.Errorf("unexpected snapshot: %+v", snap)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".mtb", "snapshots", "before.json")); err != nil {
+		t.Fatalf("expected snapshot at the repository root: %v", err)
+	}
+
+	// The stored snapshot is not itself counted as code.
+	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, lang := range output.LanguageSummary {
+		if lang.Name == "JSON" {
+			t.Fatal("expected .mtb to be excluded from analysis")
+		}
+	}
+}
+
+func TestHandleSnapshot_ListAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+	saveTestSnapshot(t, dir, "one")
+	saveTestSnapshot(t, dir, "two")
+
+	_, output, err := HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Action: "list", Path: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(output.Snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %+v", output.Snapshots)
+	}
+	if output.Snapshots[0].Commit != "" || output.Snapshots[0].Code != 1 {
+		t.Errorf("unexpected summary outside git: %+v", output.Snapshots[0])
+	}
+
+	result, _, _ := HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Action: "delete", Name: "one", Path: dir})
+	if result != nil && result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
+	_, output, _ = HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Action: "list", Path: dir})
+	if len(output.Snapshots) != 1 || output.Snapshots[0].Name != "two" {
+		t.Fatalf("expected only snapshot two, got %+v", output.Snapshots)
+	}
+
+	result, _, _ = HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Action: "delete", Name: "one", Path: dir})
+	if !result.IsError {
+		t.Fatal("expected an error deleting a missing snapshot")
+	}
+}
+
+func TestHandleSnapshot_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "../escape", ".hidden", "a/b"} {
+		result, _, _ := HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Name: name, Path: t.TempDir()})
+		if !result.IsError {
+			t.Errorf("expected an error for name %q", name)
+		}
+	}
+}
+
+func TestHandleStats_Baseline(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	saveTestSnapshot(t, dir, "before")
+	writeFiles(t, dir, map[string]string{"util.go": "package main\n\nfunc f(x int) bool {\n\tif x > 0 {\n\t\treturn true\n\t}\n\treturn false\n}\n"})
+
+	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, Baseline: "before"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Baseline == nil {
+		t.Fatal("expected a baseline delta")
+	}
+	if output.Baseline.Base != "snapshot before" || output.Baseline.Total.Code.Delta != 7 || output.Baseline.Total.Complexity.Delta != 1 {
+		t.Fatalf("unexpected baseline delta: %+v", output.Baseline.Total)
+	}
+
+	result, _, _ := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, Baseline: "missing"})
+	if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "not found") {
+		t.Fatal("expected an error for a missing baseline")
+	}
+}
+
+func TestHandleCompare_BaseSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {}\n", "style.css": "body { color: red; }\n"})
+	// The snapshot's filters carry over to the comparison.
+	_, _, err := HandleSnapshot(context.Background(), &mcp.CallToolRequest{}, SnapshotInput{Name: "before", Path: dir, ExcludeExtensions: []string{"css"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writeFiles(t, dir, map[string]string{"more.go": "package main\n\nfunc g() {}\n"})
+
+	result, output, err := HandleCompare(context.Background(), &mcp.CallToolRequest{}, CompareInput{Project: "test", Path: dir, BaseSnapshot: "before"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
+	if output.Base != "snapshot before" || output.Head != workingTree {
+		t.Fatalf("unexpected sides: %s → %s", output.Base, output.Head)
+	}
+	if len(output.Languages) != 1 || output.Languages[0].Name != "Go" || output.Total.Code.Delta != 2 {
+		t.Fatalf("unexpected delta: %+v", output.Languages)
+	}
+
+	result, _, _ = HandleCompare(context.Background(), &mcp.CallToolRequest{}, CompareInput{Project: "test", Path: dir, Base: "HEAD", BaseSnapshot: "before"})
+	if !result.IsError {
+		t.Fatal("expected base and base_snapshot to be mutually exclusive")
+	}
+}
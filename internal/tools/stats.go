@@ -5,21 +5,16 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
-	"sync"
+	"slices"
+	"sort"
 
 	"github.com/boyter/scc/v3/processor"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// sccMu guards scc's global processor state and the os.Stdout redirect
-// against concurrent access. The MCP StdioTransport captures os.Stdout at
-// connection time, so reassigning the variable does not affect transport
-// writes — but the mutex ensures scc calls are serialized and stdout is
-// restored reliably.
-var sccMu sync.Mutex
-
 type StatsInput struct {
 	Path              string   `json:"path" jsonschema:"path to directory or file to analyze"`
 	Cocomo            *bool    `json:"cocomo,omitempty" jsonschema:"include COCOMO cost estimates (default true)"`
@@ -27,6 +22,14 @@ type StatsInput struct {
 	ExcludeDir        []string `json:"exclude_dir,omitempty" jsonschema:"directories to exclude from analysis"`
 	ExcludeExtensions []string `json:"exclude_ext,omitempty" jsonschema:"file extensions to exclude (e.g. min.js)"`
 	IncludeExtensions []string `json:"include_ext,omitempty" jsonschema:"only include these file extensions"`
+	Files             bool     `json:"files,omitempty" jsonschema:"include a per-file breakdown"`
+	TopN              int      `json:"top_n,omitempty" jsonschema:"rank the N most complex files"`
+	Baseline          string   `json:"baseline,omitempty" jsonschema:"name of a saved snapshot to diff the results against"`
+	ProjectType       string   `json:"project_type,omitempty" jsonschema:"COCOMO project type: organic (default), semi-detached, or embedded"`
+	AverageWage       int64    `json:"average_wage,omitempty" jsonschema:"average annual developer wage for COCOMO (default 56286)"`
+	Overhead          float64  `json:"overhead,omitempty" jsonschema:"overhead multiplier on wages for COCOMO (default 2.4)"`
+	EAF               float64  `json:"eaf,omitempty" jsonschema:"COCOMO effort adjustment factor (default 1.0)"`
+	Currency          string   `json:"currency,omitempty" jsonschema:"currency symbol for cost estimates (default $)"`
 }
 
 type LanguageSummary struct {
@@ -40,15 +43,67 @@ type LanguageSummary struct {
 	Count      int64  `json:"Count"`
 }
 
+type FileSummary struct {
+	Location   string `json:"Location"`
+	Language   string `json:"Language"`
+	Bytes      int64  `json:"Bytes"`
+	Lines      int64  `json:"Lines"`
+	Code       int64  `json:"Code"`
+	Complexity int64  `json:"Complexity"`
+}
+
 type StatsOutput struct {
 	LanguageSummary         []LanguageSummary `json:"languageSummary"`
 	EstimatedCost           float64           `json:"estimatedCost"`
 	EstimatedScheduleMonths float64           `json:"estimatedScheduleMonths"`
 	EstimatedPeople         float64           `json:"estimatedPeople"`
+	// Cocomo is the model the estimates were made with, or nil without
+	// estimates.
+	Cocomo      *CocomoModel  `json:"cocomo,omitempty"`
+	Files       []FileSummary `json:"files,omitempty"`
+	MostComplex []FileSummary `json:"mostComplex,omitempty"`
+	Warnings    []string      `json:"warnings,omitempty"`
+	// Baseline is the delta from the snapshot named in the input.
+	Baseline *CompareOutput `json:"baseline,omitempty"`
+}
+
+// sccOutput mirrors scc's json2 format, which nests per-file records under
+// each language.
+type sccOutput struct {
+	LanguageSummary []struct {
+		LanguageSummary
+		Files []FileSummary `json:"Files"`
+	} `json:"languageSummary"`
+	EstimatedCost           float64 `json:"estimatedCost"`
+	EstimatedScheduleMonths float64 `json:"estimatedScheduleMonths"`
+	EstimatedPeople         float64 `json:"estimatedPeople"`
 }
 
 // RunSCC runs scc on the given absolute path and returns analysis results.
-func RunSCC(absPath string, cocomo, complexity bool, excludeDir, excludeExt, includeExt []string) (*StatsOutput, error) {
+// COCOMO estimates use the given model, and are left out when it is nil.
+// Files carries every analyzed file, sorted by location relative to absPath.
+// Analyses run in pooled worker processes, so concurrent calls proceed in
+// parallel up to the configured number of workers.
+func RunSCC(ctx context.Context, absPath string, cocomo *CocomoModel, complexity bool, excludeDir, excludeExt, includeExt []string) (*StatsOutput, error) {
+	return currentPool().analyze(ctx, sccRequest{
+		Path:       absPath,
+		Cocomo:     cocomo,
+		Complexity: complexity,
+		ExcludeDir: excludeDir,
+		ExcludeExt: excludeExt,
+		IncludeExt: includeExt,
+	})
+}
+
+// runSCCInProcess configures scc's globals for req and runs it. It must only
+// be called from a worker, which handles one request at a time.
+func runSCCInProcess(req sccRequest) (*StatsOutput, error) {
+	absPath := req.Path
+	// scc exits the process when a path cannot be read.
+	if _, err := os.Stat(absPath); err != nil {
+		return nil, err
+	}
+
 	tmpFile, err := os.CreateTemp("", "mtb-*.json")
 	if err != nil {
 		return nil, err
@@ -57,23 +112,29 @@ func RunSCC(absPath string, cocomo, complexity bool, excludeDir, excludeExt, inc
 	tmpFile.Close()
 	defer os.Remove(tmpPath)
 
-	sccMu.Lock()
-	defer sccMu.Unlock()
-
 	processor.DirFilePaths = []string{absPath}
 	processor.Format = "json2"
 	processor.FileOutput = tmpPath
+	// json2 only nests per-file records when Files is set.
+	processor.Files = true
 	// scc flags use negative semantics: true = disable the feature
-	processor.Cocomo = !cocomo
-	processor.Complexity = !complexity
-	processor.PathDenyList = excludeDir
-	processor.ExcludeListExtensions = excludeExt
-	processor.AllowListExtensions = includeExt
+	processor.Cocomo = req.Cocomo == nil
+	processor.Complexity = !req.Complexity
+	// .mtb holds mtb's own snapshots, not the project's code.
+	processor.PathDenyList = append(slices.Clone(req.ExcludeDir), ".mtb")
+	processor.ExcludeListExtensions = req.ExcludeExt
+	processor.AllowListExtensions = req.IncludeExt
+	if req.Cocomo != nil {
+		processor.CocomoProjectType = req.Cocomo.ProjectType
+		processor.AverageWage = req.Cocomo.AverageWage
+		processor.Overhead = req.Cocomo.Overhead
+		processor.EAF = req.Cocomo.EAF
+	}
 
 	// Suppress scc's console output by redirecting os.Stdout to /dev/null.
-	// This is safe because StdioTransport.Connect() captures os.Stdout into
-	// a stored io.Writer at connection time — it does not re-read the variable.
-	// The deferred restore ensures stdout is recovered even if scc panics.
+	// This is safe because the worker captured os.Stdout for its responses
+	// before any analysis ran — it does not re-read the variable. The
+	// deferred restore ensures stdout is recovered even if scc panics.
 	oldStdout := os.Stdout
 	devNull, err := os.Open(os.DevNull)
 	if err != nil {
@@ -90,15 +151,67 @@ func RunSCC(absPath string, cocomo, complexity bool, excludeDir, excludeExt, inc
 	if err != nil {
 		return nil, err
 	}
+	// scc fails to encode its NaN estimates when there is no code at all and
+	// writes nothing.
+	if len(data) == 0 {
+		return &StatsOutput{Cocomo: req.Cocomo}, nil
+	}
 
-	var output StatsOutput
-	if err := json.Unmarshal(data, &output); err != nil {
+	var raw sccOutput
+	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, err
 	}
 
+	output := StatsOutput{
+		EstimatedCost:           raw.EstimatedCost,
+		EstimatedScheduleMonths: raw.EstimatedScheduleMonths,
+		EstimatedPeople:         raw.EstimatedPeople,
+		Cocomo:                  req.Cocomo,
+	}
+	for _, lang := range raw.LanguageSummary {
+		output.LanguageSummary = append(output.LanguageSummary, lang.LanguageSummary)
+		for _, f := range lang.Files {
+			f.Location = relativeLocation(absPath, f.Location)
+			output.Files = append(output.Files, f)
+		}
+	}
+	sort.Slice(output.Files, func(i, j int) bool {
+		return output.Files[i].Location < output.Files[j].Location
+	})
+
 	return &output, nil
 }
 
+// relativeLocation returns loc relative to root using forward slashes. When
+// root is itself a file, its base name is returned.
+func relativeLocation(root, loc string) string {
+	rel, err := filepath.Rel(root, loc)
+	if err != nil || rel == "." {
+		return filepath.ToSlash(filepath.Base(loc))
+	}
+	return filepath.ToSlash(rel)
+}
+
+// mostComplex returns the n files with the highest complexity, breaking
+// ties by code lines and then location.
+func mostComplex(files []FileSummary, n int) []FileSummary {
+	ranked := slices.Clone(files)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Complexity != b.Complexity {
+			return a.Complexity > b.Complexity
+		}
+		if a.Code != b.Code {
+			return a.Code > b.Code
+		}
+		return a.Location < b.Location
+	})
+	if n < len(ranked) {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
 func HandleStats(ctx context.Context, req *mcp.CallToolRequest, input StatsInput) (*mcp.CallToolResult, StatsOutput, error) {
 	path := input.Path
 	if path == "" {
@@ -110,13 +223,75 @@ func HandleStats(ctx context.Context, req *mcp.CallToolRequest, input StatsInput
 		return ErrResult[StatsOutput]("invalid path: " + err.Error())
 	}
 
-	cocomo := input.Cocomo == nil || *input.Cocomo
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[StatsOutput](err.Error())
+	}
+
+	model, err := resolveCocomo(CocomoModel{
+		ProjectType: input.ProjectType,
+		AverageWage: input.AverageWage,
+		Overhead:    input.Overhead,
+		EAF:         input.EAF,
+		Currency:    input.Currency,
+	}, cfg.Cocomo)
+	if err != nil {
+		return ErrResult[StatsOutput](err.Error())
+	}
+	var cocomo *CocomoModel
+	if input.Cocomo == nil || *input.Cocomo {
+		cocomo = &model
+	}
 	complexity := input.Complexity == nil || *input.Complexity
 
-	output, err := RunSCC(absPath, cocomo, complexity, input.ExcludeDir, input.ExcludeExtensions, input.IncludeExtensions)
+	// A baseline's filters take precedence over the config so both sides
+	// count the same files.
+	defaults := SnapshotFilters{
+		ExcludeDir:        cfg.Stats.ExcludeDir,
+		ExcludeExtensions: cfg.Stats.ExcludeExtensions,
+		IncludeExtensions: cfg.Stats.IncludeExtensions,
+	}
+	var baseline *Snapshot
+	if input.Baseline != "" {
+		if baseline, err = loadSnapshot(ctx, absPath, input.Baseline); err != nil {
+			return ErrResult[StatsOutput](err.Error())
+		}
+		defaults = baseline.Filters
+	}
+
+	output, err := RunSCC(ctx, absPath, cocomo, complexity,
+		orDefault(input.ExcludeDir, defaults.ExcludeDir),
+		orDefault(input.ExcludeExtensions, defaults.ExcludeExtensions),
+		orDefault(input.IncludeExtensions, defaults.IncludeExtensions))
 	if err != nil {
 		return ErrResult[StatsOutput]("analysis failed: " + err.Error())
 	}
 
+	if baseline != nil {
+		delta := diffStats(&baseline.Stats, output, model)
+		delta.Base = "snapshot " + baseline.Name
+		delta.Head = workingTree
+		delta.Table = renderDeltaTable(delta)
+		delta.Warnings = append(delta.Warnings, baselineWarnings(ctx, baseline, absPath, model)...)
+		output.Baseline = &delta
+	}
+
+	if limit := cfg.Thresholds.FileComplexity; limit > 0 {
+		for _, f := range mostComplex(output.Files, len(output.Files)) {
+			if f.Complexity <= limit {
+				break
+			}
+			output.Warnings = append(output.Warnings, fmt.Sprintf("%s has complexity %d, above the project threshold of %d", f.Location, f.Complexity, limit))
+		}
+	}
+
+	if input.TopN > 0 {
+		output.MostComplex = mostComplex(output.Files, input.TopN)
+	}
+	if !input.Files {
+		output.Files = nil
+	}
+
+	publishStats(ctx, absPath, *output)
 	return nil, *output, nil
 }
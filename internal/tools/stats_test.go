@@ -4,6 +4,7 @@ package tools
 
 import (
 	"context"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
@@ -43,10 +44,13 @@ func TestHandleStats_KnownFiles(t *testing.T) {
 func TestHandleStats_EmptyDir(t *testing.T) {
 	dir := t.TempDir()
 
-	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir})
+	result, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if result != nil && result.IsError {
+		t.Fatalf("unexpected error result: %v", result.Content)
+	}
 
 	if len(output.LanguageSummary) != 0 {
 		t.Fatalf("expected empty language summary for empty dir, got %d entries", len(output.LanguageSummary))
@@ -114,3 +118,86 @@ func TestHandleStats_BooleanDefaults(t *testing.T) {
 		t.Fatal("expected non-zero estimated cost with default (enabled) cocomo")
 	}
 }
+
+func TestHandleStats_Files(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "pkg"), 0755)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "pkg", "lib.go"), []byte("package pkg\n\nfunc F(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\treturn 0\n}\n"), 0644)
+
+	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, Files: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(output.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(output.Files))
+	}
+	if output.Files[0].Location != "main.go" || output.Files[1].Location != "pkg/lib.go" {
+		t.Fatalf("expected relative locations sorted by path, got %q and %q", output.Files[0].Location, output.Files[1].Location)
+	}
+	if output.Files[1].Language != "Go" || output.Files[1].Code == 0 || output.Files[1].Bytes == 0 {
+		t.Fatalf("expected populated per-file metrics, got %+v", output.Files[1])
+	}
+	if output.MostComplex != nil {
+		t.Fatal("expected no ranking without top_n")
+	}
+}
+
+func TestHandleStats_TopN(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "simple.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "complex.go"), []byte("package main\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\tfor i := 0; i < x; i++ {\n\t}\n\treturn 0\n}\n"), 0644)
+
+	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, TopN: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output.Files != nil {
+		t.Fatal("expected per-file breakdown to be omitted unless requested")
+	}
+	if len(output.MostComplex) != 1 || output.MostComplex[0].Location != "complex.go" {
+		t.Fatalf("expected complex.go ranked first, got %+v", output.MostComplex)
+	}
+}
+
+func TestHandleStats_CocomoModel(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":   complexGo,
+		".mtb.yaml": "cocomo:\n  average_wage: 120000\n  currency: €\n",
+	})
+
+	_, def, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, Currency: "$", AverageWage: 56286})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Cocomo == nil || *def.Cocomo != defaultCocomo() {
+		t.Fatalf("expected scc's default model to be echoed, got %+v", def.Cocomo)
+	}
+
+	_, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, ProjectType: "Embedded", Overhead: 1.5, EAF: 1.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CocomoModel{ProjectType: "embedded", AverageWage: 120000, Overhead: 1.5, EAF: 1.2, Currency: "€"}
+	if output.Cocomo == nil || *output.Cocomo != want {
+		t.Fatalf("expected the input over the config over the defaults, got %+v", output.Cocomo)
+	}
+	// The worker estimates the total with the same model mtb uses per
+	// language.
+	if got, est := want.cost(totalCode(output)), output.EstimatedCost; est <= def.EstimatedCost || math.Abs(got-est) > 0.01 {
+		t.Errorf("expected an estimate of %.2f above the default %.2f, got %.2f", got, def.EstimatedCost, est)
+	}
+
+	_, off, _ := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, Cocomo: new(bool)})
+	if off.Cocomo != nil {
+		t.Errorf("expected no model without estimates, got %+v", off.Cocomo)
+	}
+
+	result, _, _ := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir, ProjectType: "agile"})
+	if result == nil || !result.IsError {
+		t.Error("expected an error for an unknown project type")
+	}
+}
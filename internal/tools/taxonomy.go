@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed taxonomy.json
+var taxonomyJSON []byte
+
+// Capability is a kind of functionality that well-known packages provide,
+// e.g. logging or HTTP clients. Packages lists them by ecosystem.
+type Capability struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Packages map[string][]string `json:"packages"`
+}
+
+// DuplicateCapability is a capability the project pulls in more than one
+// direct dependency for, within one ecosystem.
+type DuplicateCapability struct {
+	Ecosystem  string   `json:"ecosystem"`
+	Capability string   `json:"capability"`
+	Name       string   `json:"name"`
+	Packages   []string `json:"packages"`
+}
+
+// StdlibEquivalent is a package whose job the language or its standard
+// library now does, e.g. github.com/pkg/errors and Go's errors.
+type StdlibEquivalent struct {
+	Ecosystem   string `json:"ecosystem"`
+	Package     string `json:"package"`
+	Replacement string `json:"replacement"`
+	// Since is the first release with the replacement, e.g. "Go 1.21".
+	Since string `json:"since"`
+	Note  string `json:"note"`
+}
+
+type taxonomy struct {
+	Capabilities []Capability       `json:"capabilities"`
+	Stdlib       []StdlibEquivalent `json:"stdlib"`
+	// byPackage indexes capabilities by ecosystem and normalized package
+	// name; stdlib does the same for standard library equivalents.
+	byPackage map[string]map[string]*Capability
+	stdlib    map[string]map[string]*StdlibEquivalent
+}
+
+// loadTaxonomy decodes the embedded capability taxonomy once. Like the
+// catalog, it ships inside the binary so lookups work offline.
+var loadTaxonomy = sync.OnceValue(func() *taxonomy {
+	var t taxonomy
+	if err := json.Unmarshal(taxonomyJSON, &t); err != nil {
+		panic("tools: invalid embedded taxonomy: " + err.Error())
+	}
+	t.byPackage = map[string]map[string]*Capability{}
+	for i := range t.Capabilities {
+		c := &t.Capabilities[i]
+		for eco, names := range c.Packages {
+			if t.byPackage[eco] == nil {
+				t.byPackage[eco] = map[string]*Capability{}
+			}
+			for _, name := range names {
+				t.byPackage[eco][normalizePackage(eco, name)] = c
+			}
+		}
+	}
+	t.stdlib = map[string]map[string]*StdlibEquivalent{}
+	for i := range t.Stdlib {
+		e := &t.Stdlib[i]
+		if t.stdlib[e.Ecosystem] == nil {
+			t.stdlib[e.Ecosystem] = map[string]*StdlibEquivalent{}
+		}
+		t.stdlib[e.Ecosystem][normalizePackage(e.Ecosystem, e.Package)] = e
+	}
+	return &t
+})
+
+// lookupCapability returns the capability a package provides, if the
+// taxonomy knows it.
+func lookupCapability(ecosystem, name string) (*Capability, bool) {
+	c, ok := loadTaxonomy().byPackage[ecosystem][normalizePackage(ecosystem, name)]
+	return c, ok
+}
+
+// lookupStdlib returns the standard library equivalent of a package, if the
+// taxonomy knows one.
+func lookupStdlib(ecosystem, name string) (*StdlibEquivalent, bool) {
+	e, ok := loadTaxonomy().stdlib[ecosystem][normalizePackage(ecosystem, name)]
+	return e, ok
+}
+
+var (
+	goMajorSuffix  = regexp.MustCompile(`/v[0-9]+$`)
+	pypiSeparators = regexp.MustCompile(`[-_.]+`)
+)
+
+// normalizePackage maps the spellings an ecosystem treats as the same
+// package onto one name: Go modules lose their major version suffix, PyPI
+// names are normalized per PEP 503, and crate names treat '-' and '_'
+// alike.
+func normalizePackage(ecosystem, name string) string {
+	switch ecosystem {
+	case EcosystemGo:
+		return goMajorSuffix.ReplaceAllString(name, "")
+	case EcosystemPyPI:
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case EcosystemCargo:
+		return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+	return strings.ToLower(name)
+}
+
+// findDuplicates returns the capabilities covered by more than one direct
+// dependency in the same ecosystem. Indirect dependencies are the choice of
+// the packages that pull them in, so they are not counted.
+func findDuplicates(deps []Dependency) []DuplicateCapability {
+	type key struct{ ecosystem, capability string }
+	groups := map[key]*DuplicateCapability{}
+	seen := map[key]map[string]bool{}
+	for _, d := range deps {
+		if !d.Direct {
+			continue
+		}
+		c, ok := lookupCapability(d.Ecosystem, d.Name)
+		if !ok {
+			continue
+		}
+		k := key{d.Ecosystem, c.ID}
+		if groups[k] == nil {
+			groups[k] = &DuplicateCapability{Ecosystem: d.Ecosystem, Capability: c.ID, Name: c.Name}
+			seen[k] = map[string]bool{}
+		}
+		// The same package in several manifests of a monorepo is one
+		// choice, not a duplicate.
+		if n := normalizePackage(d.Ecosystem, d.Name); !seen[k][n] {
+			seen[k][n] = true
+			groups[k].Packages = append(groups[k].Packages, d.Name)
+		}
+	}
+
+	var dups []DuplicateCapability
+	for _, g := range groups {
+		if len(g.Packages) > 1 {
+			sort.Strings(g.Packages)
+			dups = append(dups, *g)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].Ecosystem != dups[j].Ecosystem {
+			return dups[i].Ecosystem < dups[j].Ecosystem
+		}
+		return dups[i].Capability < dups[j].Capability
+	})
+	return dups
+}
+
+// duplicatesGuidance asks the agent to raise each duplicate with the user.
+func duplicatesGuidance(dups []DuplicateCapability) string {
+	var b strings.Builder
+	b.WriteString(" DUPLICATE FUNCTIONALITY: the project depends on more than one library for the same job:")
+	for _, d := range dups {
+		fmt.Fprintf(&b, " %s (%s): %s;", d.Name, d.Ecosystem, strings.Join(d.Packages, ", "))
+	}
+	b.WriteString(" Point each one out to the user and ask whether the project could standardize on one of them. " +
+		"Do NOT remove or replace a dependency without the user's approval.")
+	return b.String()
+}
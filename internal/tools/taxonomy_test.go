@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadTaxonomy(t *testing.T) {
+	known := map[string]bool{EcosystemGo: true, EcosystemNPM: true, EcosystemPyPI: true, EcosystemCargo: true}
+	ids := map[string]bool{}
+	owner := map[string]string{}
+	for _, c := range loadTaxonomy().Capabilities {
+		if c.ID == "" || c.Name == "" || len(c.Packages) == 0 {
+			t.Errorf("capability %q is incomplete", c.ID)
+		}
+		if ids[c.ID] {
+			t.Errorf("duplicate capability id %q", c.ID)
+		}
+		ids[c.ID] = true
+		for eco, names := range c.Packages {
+			if !known[eco] {
+				t.Errorf("%s: unknown ecosystem %q", c.ID, eco)
+			}
+			for _, name := range names {
+				// A package must map to a single capability for lookups to
+				// be unambiguous.
+				k := eco + ":" + normalizePackage(eco, name)
+				if prev, ok := owner[k]; ok {
+					t.Errorf("%s is listed under both %s and %s", k, prev, c.ID)
+				}
+				owner[k] = c.ID
+			}
+		}
+	}
+}
+
+func TestLookupCapability(t *testing.T) {
+	cases := []struct{ ecosystem, name, want string }{
+		{EcosystemGo, "github.com/labstack/echo/v4", "web-framework"},
+		{EcosystemGo, "gopkg.in/yaml.v3", "yaml"},
+		{EcosystemNPM, "Axios", "http-client"},
+		{EcosystemPyPI, "Ruamel_YAML", "yaml"},
+		{EcosystemCargo, "env-logger", "logging"},
+		{EcosystemCargo, "mockito", "http-mocking"},
+		{EcosystemCargo, "mockall", "mocking"},
+		{EcosystemNPM, "mongoose", "odm"},
+		{EcosystemNPM, "typeorm", "orm"},
+		{EcosystemGo, "example.com/unknown", ""},
+	}
+	for _, c := range cases {
+		got := ""
+		if capability, ok := lookupCapability(c.ecosystem, c.name); ok {
+			got = capability.ID
+		}
+		if got != c.want {
+			t.Errorf("lookupCapability(%s, %s) = %q, want %q", c.ecosystem, c.name, got, c.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	deps := []Dependency{
+		{Ecosystem: EcosystemGo, Name: "github.com/sirupsen/logrus", Direct: true},
+		{Ecosystem: EcosystemGo, Name: "go.uber.org/zap", Direct: true},
+		{Ecosystem: EcosystemGo, Name: "github.com/rs/zerolog", Direct: false},
+		{Ecosystem: EcosystemNPM, Name: "axios", Direct: true, Manifest: "web/package.json"},
+		{Ecosystem: EcosystemNPM, Name: "axios", Direct: true, Manifest: "api/package.json"},
+		{Ecosystem: EcosystemNPM, Name: "node-fetch", Direct: true},
+		{Ecosystem: EcosystemNPM, Name: "winston", Direct: true},
+		{Ecosystem: EcosystemPyPI, Name: "requests", Direct: true},
+	}
+	want := []DuplicateCapability{
+		{Ecosystem: EcosystemGo, Capability: "logging", Name: "Logging", Packages: []string{"github.com/sirupsen/logrus", "go.uber.org/zap"}},
+		{Ecosystem: EcosystemNPM, Capability: "http-client", Name: "HTTP client", Packages: []string{"axios", "node-fetch"}},
+	}
+	if got := findDuplicates(deps); !reflect.DeepEqual(got, want) {
+		t.Errorf("findDuplicates() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadTaxonomy_Stdlib(t *testing.T) {
+	seen := map[string]bool{}
+	for _, e := range loadTaxonomy().Stdlib {
+		if e.Package == "" || e.Replacement == "" || e.Since == "" || e.Note == "" {
+			t.Errorf("stdlib entry %+v is incomplete", e)
+		}
+		k := e.Ecosystem + ":" + normalizePackage(e.Ecosystem, e.Package)
+		if seen[k] {
+			t.Errorf("duplicate stdlib entry %s", k)
+		}
+		seen[k] = true
+	}
+	if e, ok := lookupStdlib(EcosystemGo, "golang.org/x/exp/slices"); !ok || e.Replacement != "slices" {
+		t.Errorf("lookupStdlib(go, golang.org/x/exp/slices) = %+v, %v", e, ok)
+	}
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"strings"
+)
+
+// tomlEntry is a single key = value assignment from a TOML document. The
+// value is left raw; use tomlString, tomlStrings, or tomlInline to decode it.
+type tomlEntry struct {
+	// Table is the dotted name of the enclosing table, e.g. "dependencies"
+	// or "target.'cfg(unix)'.dependencies".
+	Table string
+	// Index counts [[array]] tables with the same name, starting at 0, so
+	// entries from different [[package]] blocks can be told apart.
+	Index int
+	// TableLine is the line of the enclosing table header.
+	TableLine int
+	Key       string
+	Value     string
+	Line      int
+}
+
+// parseTOML is a line-oriented reader for the subset of TOML used by
+// dependency manifests and lockfiles: tables, arrays of tables, and
+// key = value pairs whose arrays may span lines. It is deliberately lenient
+// and skips anything it does not understand.
+func parseTOML(content string) []tomlEntry {
+	var entries []tomlEntry
+	table := ""
+	index, tableLine := 0, 0
+	arrayCounts := map[string]int{}
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+			table = strings.TrimSpace(line[2 : len(line)-2])
+			index = arrayCounts[table]
+			arrayCounts[table]++
+			tableLine = i + 1
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			index, tableLine = 0, i+1
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		entry := tomlEntry{Table: table, Index: index, TableLine: tableLine, Key: unquoteTOMLKey(key), Line: i + 1}
+
+		value = strings.TrimSpace(value)
+		// Multi-line arrays and inline tables continue until brackets balance.
+		for depth := bracketDepth(value); depth > 0 && i+1 < len(lines); depth = bracketDepth(value) {
+			i++
+			value += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+		}
+		entry.Value = value
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// stripTOMLComment removes a trailing # comment that is not inside a string.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// bracketDepth returns the number of unclosed [ and { outside strings.
+func bracketDepth(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+func unquoteTOMLKey(key string) string {
+	key = strings.TrimSpace(key)
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		return key[1 : len(key)-1]
+	}
+	return key
+}
+
+// tomlString decodes a basic or literal string value. Non-string values are
+// returned as-is.
+func tomlString(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		inner := value[1 : len(value)-1]
+		if value[0] == '"' {
+			inner = strings.ReplaceAll(inner, `\"`, `"`)
+			inner = strings.ReplaceAll(inner, `\\`, `\`)
+		}
+		return inner
+	}
+	return value
+}
+
+// tomlStrings decodes an array of strings. Nested arrays and inline tables
+// are returned raw.
+func tomlStrings(value string) []string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil
+	}
+	var out []string
+	for _, item := range splitTOMLList(value[1 : len(value)-1]) {
+		out = append(out, tomlString(item))
+	}
+	return out
+}
+
+// tomlInline decodes a one-level inline table such as
+// { version = "1.0", features = ["derive"] } into raw values by key.
+func tomlInline(value string) map[string]string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		return nil
+	}
+	out := map[string]string{}
+	for _, item := range splitTOMLList(value[1 : len(value)-1]) {
+		if k, v, ok := strings.Cut(item, "="); ok {
+			out[unquoteTOMLKey(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+// splitTOMLList splits comma-separated items at the top nesting level.
+func splitTOMLList(s string) []string {
+	var items []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			if item := strings.TrimSpace(s[start:i]); item != "" {
+				items = append(items, item)
+			}
+			start = i + 1
+		}
+	}
+	if item := strings.TrimSpace(s[start:]); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	entries := parseTOML(`# top comment
+name = "demo" # trailing comment
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+"quoted-key" = 'literal # not a comment'
+
+[[package]]
+name = "a"
+deps = [
+  "x",
+  "y", # comment inside array
+]
+
+[[package]]
+name = "b"
+`)
+
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 entries, got %d: %+v", len(entries), entries)
+	}
+
+	if entries[0].Table != "" || entries[0].Key != "name" || tomlString(entries[0].Value) != "demo" {
+		t.Errorf("unexpected root entry: %+v", entries[0])
+	}
+
+	serde := entries[1]
+	if serde.Table != "dependencies" || serde.Line != 4 {
+		t.Errorf("unexpected serde entry: %+v", serde)
+	}
+	inline := tomlInline(serde.Value)
+	if tomlString(inline["version"]) != "1.0" {
+		t.Errorf("expected inline version 1.0, got %q", inline["version"])
+	}
+	if got := tomlStrings(inline["features"]); len(got) != 1 || got[0] != "derive" {
+		t.Errorf("expected features [derive], got %v", got)
+	}
+
+	if entries[2].Key != "quoted-key" || tomlString(entries[2].Value) != "literal # not a comment" {
+		t.Errorf("unexpected quoted entry: %+v", entries[2])
+	}
+
+	deps := entries[4]
+	if deps.Table != "package" || deps.Index != 0 {
+		t.Errorf("unexpected array table entry: %+v", deps)
+	}
+	if got := tomlStrings(deps.Value); len(got) != 2 || got[0] != "x" || got[1] != "y" {
+		t.Errorf("expected multi-line array [x y], got %v", got)
+	}
+
+	if entries[5].Table != "package" || entries[5].Index != 1 {
+		t.Errorf("expected second [[package]] to have index 1, got %+v", entries[5])
+	}
+}
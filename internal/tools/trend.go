@@ -0,0 +1,397 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// trendCacheDir holds the analyses of past commits, relative to the
+// repository root. A commit's tree never changes, so entries never expire.
+const trendCacheDir = ".mtb/trend"
+
+type TrendInput struct {
+	Path              string   `json:"path,omitempty" jsonschema:"directory inside the git repository to analyze (default .)"`
+	Since             string   `json:"since,omitempty" jsonschema:"only sample commits newer than this git date, e.g. 6 months ago or 2024-01-01 (default 1 year ago)"`
+	Every             int      `json:"every,omitempty" jsonschema:"sample every N commits instead of by interval"`
+	Interval          string   `json:"interval,omitempty" jsonschema:"sample the last commit of each week or month (default month)"`
+	MaxPoints         int      `json:"max_points,omitempty" jsonschema:"keep at most this many of the most recent samples (default 52)"`
+	Render            string   `json:"render,omitempty" jsonschema:"also render the series as a sparkline or csv"`
+	ExcludeDir        []string `json:"exclude_dir,omitempty" jsonschema:"directories to exclude from analysis"`
+	ExcludeExtensions []string `json:"exclude_ext,omitempty" jsonschema:"file extensions to exclude (e.g. min.js)"`
+	IncludeExtensions []string `json:"include_ext,omitempty" jsonschema:"only include these file extensions"`
+}
+
+type TrendLanguage struct {
+	Name          string  `json:"name"`
+	Code          int64   `json:"code"`
+	Complexity    int64   `json:"complexity"`
+	EstimatedCost float64 `json:"estimatedCost"`
+}
+
+// TrendPoint is the analysis of one sampled commit.
+type TrendPoint struct {
+	Commit        string          `json:"commit"`
+	Date          time.Time       `json:"date"`
+	Code          int64           `json:"code"`
+	Complexity    int64           `json:"complexity"`
+	EstimatedCost float64         `json:"estimatedCost"`
+	Languages     []TrendLanguage `json:"languages"`
+}
+
+type TrendOutput struct {
+	Since    string       `json:"since"`
+	Sampling string       `json:"sampling"`
+	Points   []TrendPoint `json:"points"`
+	// Cocomo is the model every point's costs were estimated with.
+	Cocomo CocomoModel `json:"cocomo"`
+	// Cached is how many points were read from the cache instead of
+	// analyzed.
+	Cached    int    `json:"cached"`
+	Table     string `json:"table"`
+	Sparkline string `json:"sparkline,omitempty"`
+	CSV       string `json:"csv,omitempty"`
+	Guidance  string `json:"guidance"`
+}
+
+// trendCommit is a commit in the sampled history.
+type trendCommit struct {
+	hash string
+	date time.Time
+}
+
+// trendCacheEntry is the stored analysis of a commit's tree.
+type trendCacheEntry struct {
+	Commit  string          `json:"commit"`
+	Path    string          `json:"path"`
+	Filters SnapshotFilters `json:"filters"`
+	Stats   StatsOutput     `json:"stats"`
+}
+
+func HandleTrend(ctx context.Context, req *mcp.CallToolRequest, input TrendInput) (*mcp.CallToolResult, TrendOutput, error) {
+	path := input.Path
+	if path == "" {
+		path = "."
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ErrResult[TrendOutput]("invalid path: " + err.Error())
+	}
+	if input.Every < 0 || input.MaxPoints < 0 {
+		return ErrResult[TrendOutput]("every and max_points must not be negative")
+	}
+	if input.Every > 0 && input.Interval != "" {
+		return ErrResult[TrendOutput]("every and interval are mutually exclusive")
+	}
+	interval := input.Interval
+	if interval == "" && input.Every == 0 {
+		interval = "month"
+	}
+	if interval != "" && interval != "week" && interval != "month" {
+		return ErrResult[TrendOutput](fmt.Sprintf("unknown interval %q: use week or month", interval))
+	}
+	if input.Render != "" && input.Render != "sparkline" && input.Render != "csv" {
+		return ErrResult[TrendOutput](fmt.Sprintf("unknown render %q: use sparkline or csv", input.Render))
+	}
+	if _, err := runGit(ctx, absPath, "rev-parse", "--show-toplevel"); err != nil {
+		return ErrResult[TrendOutput]("trend requires a git repository: " + err.Error())
+	}
+
+	cfg, err := LoadConfig(absPath)
+	if err != nil {
+		return ErrResult[TrendOutput](err.Error())
+	}
+	filters := SnapshotFilters{
+		ExcludeDir:        orDefault(input.ExcludeDir, cfg.Stats.ExcludeDir),
+		ExcludeExtensions: orDefault(input.ExcludeExtensions, cfg.Stats.ExcludeExtensions),
+		IncludeExtensions: orDefault(input.IncludeExtensions, cfg.Stats.IncludeExtensions),
+	}
+	model, err := resolveCocomo(CocomoModel{}, cfg.Cocomo)
+	if err != nil {
+		return ErrResult[TrendOutput](err.Error())
+	}
+	since := input.Since
+	if since == "" {
+		since = "1 year ago"
+	}
+	maxPoints := input.MaxPoints
+	if maxPoints == 0 {
+		maxPoints = 52
+	}
+
+	history, err := trendHistory(ctx, absPath, since)
+	if err != nil {
+		return ErrResult[TrendOutput]("reading history failed: " + err.Error())
+	}
+	samples := sampleCommits(history, input.Every, interval)
+	if len(samples) > maxPoints {
+		samples = samples[len(samples)-maxPoints:]
+	}
+
+	output := TrendOutput{Since: since, Sampling: interval + "ly", Cocomo: model}
+	if input.Every > 0 {
+		output.Sampling = fmt.Sprintf("every %d commits", input.Every)
+	}
+	output.Points, output.Cached, err = analyzeCommits(ctx, absPath, samples, filters, model)
+	if err != nil {
+		return ErrResult[TrendOutput](err.Error())
+	}
+
+	output.Table = renderTrendTable(output.Points, model.Currency)
+	switch input.Render {
+	case "sparkline":
+		output.Sparkline = renderTrendSparkline(output.Points, model.Currency)
+	case "csv":
+		output.CSV = renderTrendCSV(output.Points)
+	}
+	if len(output.Points) == 0 {
+		output.Guidance = fmt.Sprintf("No commits under %s since %s. Tell the user there is no history to chart in this window; "+
+			"a longer window (since) may find some.", absPath, since)
+	} else {
+		first, last := output.Points[0], output.Points[len(output.Points)-1]
+		output.Guidance = fmt.Sprintf("IMPORTANT: Present the trend to the user. From %s to %s, code went from %d to %d lines, "+
+			"complexity from %d to %d, and the estimated cost from %s to %s. "+
+			"Point out the periods where complexity grew faster than code, and let the user judge whether the growth was worth it. "+
+			"Do NOT present the COCOMO cost as a precise figure — it is an estimate for comparing points in time.",
+			first.Date.Format("2006-01-02"), last.Date.Format("2006-01-02"), first.Code, last.Code,
+			first.Complexity, last.Complexity, FormatMoney(first.EstimatedCost, model.Currency), FormatMoney(last.EstimatedCost, model.Currency))
+	}
+
+	summary := fmt.Sprintf("Complexity trend since %s, sampled %s:\n\n%s", since, output.Sampling, output.Table)
+	if output.Sparkline != "" {
+		summary += "\n" + output.Sparkline
+	}
+	if output.CSV != "" {
+		summary += "\n" + output.CSV
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: summary}},
+	}, output, nil
+}
+
+// trendHistory returns the first-parent commits that touched dir since the
+// given git date, oldest first.
+func trendHistory(ctx context.Context, dir, since string) ([]trendCommit, error) {
+	out, err := runGit(ctx, dir, "log", "--first-parent", "--format=%H %ct", "--since="+since, "--", ".")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	var history []trendCommit
+	for _, line := range strings.Split(out, "\n") {
+		hash, ts, _ := strings.Cut(line, " ")
+		secs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected log line %q", line)
+		}
+		history = append(history, trendCommit{hash: hash, date: time.Unix(secs, 0).UTC()})
+	}
+	slices.Reverse(history)
+	return history, nil
+}
+
+// sampleCommits picks every nth commit counting back from the newest, or
+// the last commit of each week or month. The newest commit is always
+// included, so the series ends at the current state of the history.
+func sampleCommits(history []trendCommit, every int, interval string) []trendCommit {
+	var samples []trendCommit
+	if every > 0 {
+		for i := len(history) - 1; i >= 0; i -= every {
+			samples = append(samples, history[i])
+		}
+		slices.Reverse(samples)
+		return samples
+	}
+	bucket := func(t time.Time) string {
+		if interval == "week" {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+		return t.Format("2006-01")
+	}
+	for i, c := range history {
+		if i+1 == len(history) || bucket(history[i+1].date) != bucket(c.date) {
+			samples = append(samples, c)
+		}
+	}
+	return samples
+}
+
+// analyzeCommits analyzes the tree of each commit, reading and filling the
+// cache, and returns the points in the order of commits and how many came
+// from the cache.
+func analyzeCommits(ctx context.Context, absPath string, commits []trendCommit, filters SnapshotFilters, model CocomoModel) ([]TrendPoint, int, error) {
+	root := stateRoot(ctx, absPath)
+	key := trendCacheKey(storePath(root, absPath), filters, model)
+
+	points := make([]TrendPoint, len(commits))
+	errs := make([]error, len(commits))
+	cached := make([]bool, len(commits))
+	// Exporting trees is cheap next to analyzing them; keep only as many in
+	// flight as there are workers so temporary copies don't pile up.
+	slots := make(chan struct{}, cap(currentPool().slots))
+	var wg sync.WaitGroup
+	for i, c := range commits {
+		file := filepath.Join(root, filepath.FromSlash(trendCacheDir), c.hash+"-"+key+".json")
+		if stats, ok := loadTrendCache(file); ok {
+			points[i], cached[i] = trendPoint(c, stats, model), true
+			continue
+		}
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			stats, err := analyzeRef(ctx, absPath, c.hash, model, filters.ExcludeDir, filters.ExcludeExtensions, filters.IncludeExtensions)
+			if err != nil {
+				errs[i] = fmt.Errorf("analysis of %s failed: %w", c.hash[:7], err)
+				return
+			}
+			stats.Files = nil
+			// A failed write only means the next run analyzes the commit again.
+			saveTrendCache(file, trendCacheEntry{Commit: c.hash, Path: storePath(root, absPath), Filters: filters, Stats: *stats})
+			points[i] = trendPoint(c, stats, model)
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	hits := 0
+	for _, c := range cached {
+		if c {
+			hits++
+		}
+	}
+	return points, hits, nil
+}
+
+// trendCacheKey identifies the analyzed directory, filters, and COCOMO
+// model, which the commit alone doesn't determine.
+func trendCacheKey(path string, filters SnapshotFilters, model CocomoModel) string {
+	data, _ := json.Marshal(struct {
+		Path    string          `json:"path"`
+		Filters SnapshotFilters `json:"filters"`
+		Cocomo  CocomoModel     `json:"cocomo"`
+	}{path, filters, model})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+func loadTrendCache(file string) (*StatsOutput, bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	var entry trendCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry.Stats, true
+}
+
+func saveTrendCache(file string, entry trendCacheEntry) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+func trendPoint(c trendCommit, stats *StatsOutput, model CocomoModel) TrendPoint {
+	p := TrendPoint{Commit: c.hash, Date: c.date, EstimatedCost: stats.EstimatedCost}
+	for _, l := range stats.LanguageSummary {
+		p.Code += l.Code
+		p.Complexity += l.Complexity
+		p.Languages = append(p.Languages, TrendLanguage{
+			Name:          l.Name,
+			Code:          l.Code,
+			Complexity:    l.Complexity,
+			EstimatedCost: model.cost(l.Code),
+		})
+	}
+	return p
+}
+
+// renderTrendTable renders a markdown table of the totals at each point.
+func renderTrendTable(points []TrendPoint, currency string) string {
+	var b strings.Builder
+	b.WriteString("| Date | Commit | Code | Complexity | Est. cost |\n")
+	b.WriteString("|------|--------|------|------------|-----------|\n")
+	for _, p := range points {
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %s |\n", p.Date.Format("2006-01-02"), p.Commit[:7], p.Code, p.Complexity, FormatMoney(p.EstimatedCost, currency))
+	}
+	return b.String()
+}
+
+// renderTrendSparkline renders one sparkline per total, oldest point first.
+func renderTrendSparkline(points []TrendPoint, currency string) string {
+	if len(points) == 0 {
+		return ""
+	}
+	var code, complexity, cost []float64
+	for _, p := range points {
+		code = append(code, float64(p.Code))
+		complexity = append(complexity, float64(p.Complexity))
+		cost = append(cost, p.EstimatedCost)
+	}
+	first, last := points[0], points[len(points)-1]
+	var b strings.Builder
+	fmt.Fprintf(&b, "Code        %s  %d → %d\n", sparkline(code), first.Code, last.Code)
+	fmt.Fprintf(&b, "Complexity  %s  %d → %d\n", sparkline(complexity), first.Complexity, last.Complexity)
+	fmt.Fprintf(&b, "Est. cost   %s  %s → %s\n", sparkline(cost), FormatMoney(first.EstimatedCost, currency), FormatMoney(last.EstimatedCost, currency))
+	return b.String()
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline scales values between their minimum and maximum onto bars.
+func sparkline(values []float64) string {
+	lo, hi := slices.Min(values), slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkBars)-1))
+		}
+		b.WriteRune(sparkBars[i])
+	}
+	return b.String()
+}
+
+// renderTrendCSV renders a row per language at each point, followed by the
+// point's total.
+func renderTrendCSV(points []TrendPoint) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"date", "commit", "language", "code", "complexity", "estimated_cost"})
+	row := func(p TrendPoint, name string, code, complexity int64, cost float64) {
+		w.Write([]string{p.Date.Format("2006-01-02"), p.Commit, name,
+			strconv.FormatInt(code, 10), strconv.FormatInt(complexity, 10), strconv.FormatFloat(cost, 'f', 0, 64)})
+	}
+	for _, p := range points {
+		for _, l := range p.Languages {
+			row(p, l.Name, l.Code, l.Complexity, l.EstimatedCost)
+		}
+		row(p, "Total", p.Code, p.Complexity, p.EstimatedCost)
+	}
+	w.Flush()
+	return buf.String()
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func trendRepo(t *testing.T) string {
+	t.Helper()
+	dir := initGitRepo(t, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	writeFiles(t, dir, map[string]string{"main.go": complexGo})
+	gitCommitAll(t, dir, "grow")
+	writeFiles(t, dir, map[string]string{"lib.py": "def f(x):\n    if x:\n        return 1\n    return 0\n"})
+	gitCommitAll(t, dir, "python")
+	return dir
+}
+
+func TestTrend_Every(t *testing.T) {
+	dir := trendRepo(t)
+	// An uncommitted change must not show up in any point.
+	writeFiles(t, dir, map[string]string{"wip.go": complexGo})
+
+	_, output, err := HandleTrend(context.Background(), nil, TrendInput{Path: dir, Every: 1, Render: "csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Points) != 3 || output.Cached != 0 || output.Sampling != "every 1 commits" {
+		t.Fatalf("expected three analyzed points, got %+v", output)
+	}
+	first, last := output.Points[0], output.Points[2]
+	if first.Code != 3 || first.Complexity != 0 || len(first.Languages) != 1 {
+		t.Errorf("unexpected first point: %+v", first)
+	}
+	if last.Complexity <= output.Points[1].Complexity || len(last.Languages) != 2 || last.EstimatedCost <= first.EstimatedCost {
+		t.Errorf("expected the python commit to add complexity and cost, got %+v", last)
+	}
+	if !strings.HasPrefix(output.CSV, "date,commit,language,code,complexity,estimated_cost\n") ||
+		!strings.Contains(output.CSV, ","+first.Commit+",Total,3,0,") {
+		t.Errorf("unexpected csv:\n%s", output.CSV)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, ".mtb", "trend"))
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("expected a cache entry per commit, got %v %v", entries, err)
+	}
+	_, again, err := HandleTrend(context.Background(), nil, TrendInput{Path: dir, Every: 2, Render: "sparkline"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Points) != 2 || again.Cached != 2 || again.Points[1].Commit != last.Commit || again.Points[0].Commit != first.Commit {
+		t.Fatalf("expected the first and last commits from the cache, got %+v", again)
+	}
+	if !strings.Contains(again.Sparkline, "Complexity  ▁█") {
+		t.Errorf("unexpected sparkline:\n%s", again.Sparkline)
+	}
+}
+
+func TestTrend_Interval(t *testing.T) {
+	dir := trendRepo(t)
+	_, output, err := HandleTrend(context.Background(), nil, TrendInput{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := gitT(t, dir, "rev-parse", "HEAD")
+	if output.Sampling != "monthly" || len(output.Points) != 1 || output.Points[0].Commit != head {
+		t.Fatalf("expected one monthly point at HEAD, got %+v", output)
+	}
+	if !strings.Contains(output.Table, "| "+head[:7]+" |") || !strings.Contains(output.Guidance, "Present the trend") {
+		t.Errorf("unexpected table or guidance:\n%s\n%s", output.Table, output.Guidance)
+	}
+}
+
+func TestTrend_Errors(t *testing.T) {
+	for _, input := range []TrendInput{
+		{Path: t.TempDir()},
+		{Every: 2, Interval: "week"},
+		{Interval: "day"},
+		{Render: "svg"},
+		{Every: -1},
+	} {
+		if result, _, _ := HandleTrend(context.Background(), nil, input); result == nil || !result.IsError {
+			t.Errorf("expected an error for %+v", input)
+		}
+	}
+}
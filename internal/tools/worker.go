@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// scc keeps its configuration in package-level globals, writes to os.Stdout
+// and calls os.Exit on some errors, so two analyses cannot share a process.
+// Each analysis instead runs in a worker: the mtb binary re-executed with
+// workerEnv set, which reads sccRequests from stdin and answers each with an
+// sccResponse on stdout. Workers are pooled and reused across analyses.
+
+// workerEnv marks a process as an scc worker.
+const workerEnv = "MTB_SCC_WORKER"
+
+// workerStderrTail is how much of a worker's stderr is kept for error
+// reports, enough for a panic's message and the top of its stack.
+const workerStderrTail = 8 << 10
+
+// DefaultWorkers is the number of analyses that may run at once unless
+// SetWorkers says otherwise.
+const DefaultWorkers = 4
+
+type sccRequest struct {
+	Path       string       `json:"path"`
+	Cocomo     *CocomoModel `json:"cocomo,omitempty"`
+	Complexity bool         `json:"complexity"`
+	ExcludeDir []string     `json:"excludeDir,omitempty"`
+	ExcludeExt []string     `json:"excludeExt,omitempty"`
+	IncludeExt []string     `json:"includeExt,omitempty"`
+}
+
+type sccResponse struct {
+	Output *StatsOutput `json:"output,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// analysisError is an error reported by a worker that is still healthy.
+type analysisError string
+
+func (e analysisError) Error() string { return string(e) }
+
+// ServeWorker turns the current process into an scc worker when it was
+// started as one, and never returns in that case. It must be called at the
+// top of main, and of TestMain in packages whose tests run analyses.
+func ServeWorker() {
+	if os.Getenv(workerEnv) != "1" {
+		return
+	}
+	if err := serveWorker(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "mtb worker:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// serveWorker answers requests from in until it is closed. out is captured
+// before any analysis runs, so scc's redirect of os.Stdout cannot touch it.
+func serveWorker(in io.Reader, out io.Writer) error {
+	dec := json.NewDecoder(in)
+	enc := json.NewEncoder(out)
+	for {
+		var req sccRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		var resp sccResponse
+		output, err := runSCCInProcess(req)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Output = output
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// worker is a running worker process.
+type worker struct {
+	cmd   *exec.Cmd
+	stdin io.Closer
+	enc   *json.Encoder
+	dec   *json.Decoder
+	// stderr keeps the end of what the worker wrote to stderr, e.g. a
+	// panic from scc.
+	stderr   tailBuffer
+	waitOnce sync.Once
+}
+
+// tailBuffer is an io.Writer that keeps the last workerStderrTail bytes
+// written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - workerStderrTail; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+func startWorker() (*worker, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locating mtb binary: %w", err)
+	}
+	w := &worker{}
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), workerEnv+"=1")
+	cmd.Stderr = &w.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting worker: %w", err)
+	}
+	w.cmd, w.stdin, w.enc, w.dec = cmd, stdin, json.NewEncoder(stdin), json.NewDecoder(stdout)
+	return w, nil
+}
+
+// analyze sends one request and waits for its answer. The worker is killed
+// if ctx is cancelled first.
+func (w *worker) analyze(ctx context.Context, req sccRequest) (*StatsOutput, error) {
+	stop := context.AfterFunc(ctx, func() { w.cmd.Process.Kill() })
+	defer stop()
+
+	var resp sccResponse
+	err := w.enc.Encode(req)
+	if err == nil {
+		err = w.dec.Decode(&resp)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Wait for the worker to exit so that everything it wrote to
+		// stderr has been copied.
+		w.cmd.Process.Kill()
+		w.wait()
+		if tail := strings.TrimSpace(w.stderr.String()); tail != "" {
+			return nil, fmt.Errorf("worker failed: %w\n%s", err, tail)
+		}
+		return nil, fmt.Errorf("worker failed: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, analysisError(resp.Error)
+	}
+	return resp.Output, nil
+}
+
+// stop closes the worker's stdin, which ends its request loop.
+func (w *worker) stop() {
+	w.stdin.Close()
+	w.wait()
+}
+
+// wait waits for the worker to exit; it may be called more than once.
+func (w *worker) wait() {
+	w.waitOnce.Do(func() { w.cmd.Wait() })
+}
+
+// workerPool hands out at most size workers at a time, starting them on
+// demand and keeping idle ones for reuse.
+type workerPool struct {
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []*worker
+	closed bool
+}
+
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{slots: make(chan struct{}, max(size, 1))}
+}
+
+// testHookAcquired, when set, is called each time an analysis obtains a
+// worker.
+var testHookAcquired func()
+
+func (p *workerPool) analyze(ctx context.Context, req sccRequest) (*StatsOutput, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.slots }()
+
+	w, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	if testHookAcquired != nil {
+		testHookAcquired()
+	}
+	output, err := w.analyze(ctx, req)
+	// A worker that failed to answer may be dead or out of step with the
+	// protocol; replace it rather than reuse it.
+	var analysisErr analysisError
+	p.put(w, err == nil || errors.As(err, &analysisErr))
+	return output, err
+}
+
+func (p *workerPool) get() (*worker, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		w := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return w, nil
+	}
+	p.mu.Unlock()
+	return startWorker()
+}
+
+func (p *workerPool) put(w *worker, healthy bool) {
+	p.mu.Lock()
+	if healthy && !p.closed {
+		p.idle = append(p.idle, w)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	w.cmd.Process.Kill()
+	w.stop()
+}
+
+// close stops the idle workers. Workers still busy are stopped when they
+// are returned.
+func (p *workerPool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+	for _, w := range idle {
+		w.stop()
+	}
+}
+
+var (
+	poolMu sync.Mutex
+	pool   = newWorkerPool(DefaultWorkers)
+)
+
+// SetWorkers sets how many analyses may run at once. Idle workers from the
+// previous pool are stopped.
+func SetWorkers(n int) {
+	poolMu.Lock()
+	old := pool
+	pool = newWorkerPool(n)
+	poolMu.Unlock()
+	old.close()
+}
+
+// CloseWorkers stops all idle workers. It should be called before the
+// process exits.
+func CloseWorkers() {
+	poolMu.Lock()
+	p := pool
+	poolMu.Unlock()
+	p.close()
+}
+
+func currentPool() *workerPool {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	return pool
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: MIT
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestMain(m *testing.M) {
+	// Analyses re-execute the test binary as an scc worker.
+	if os.Getenv(workerEnv) == "1" && os.Getenv("MTB_TEST_WORKER_PANIC") != "" {
+		panic(os.Getenv("MTB_TEST_WORKER_PANIC"))
+	}
+	ServeWorker()
+	// Tests never reach the network; search tests install their own provider.
+	SetSearchProvider(OfflineSearch{})
+	// Unanswered consult sessions are saved in the user cache.
+	cache, err := os.MkdirTemp("", "mtb-cache-*")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CACHE_HOME", cache)
+	code := m.Run()
+	os.RemoveAll(cache)
+	CloseWorkers()
+	os.Exit(code)
+}
+
+func TestHandleStats_Concurrent(t *testing.T) {
+	const n = 3
+	SetWorkers(n)
+	t.Cleanup(func() { SetWorkers(DefaultWorkers) })
+
+	// Every analysis waits in the hook until all n hold a worker at once,
+	// which can only happen if they are not serialized.
+	var mu sync.Mutex
+	acquired := 0
+	timedOut := false
+	all := make(chan struct{})
+	testHookAcquired = func() {
+		mu.Lock()
+		acquired++
+		if acquired == n {
+			close(all)
+		}
+		mu.Unlock()
+		select {
+		case <-all:
+		case <-time.After(10 * time.Second):
+			mu.Lock()
+			timedOut = true
+			mu.Unlock()
+		}
+	}
+	t.Cleanup(func() { testHookAcquired = nil })
+
+	var wg sync.WaitGroup
+	errs := make([]string, n)
+	for i := range n {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"main.go": fmt.Sprintf("package main\n\nfunc f%d() {}\n", i)})
+		wg.Go(func() {
+			result, output, err := HandleStats(context.Background(), &mcp.CallToolRequest{}, StatsInput{Path: dir})
+			switch {
+			case err != nil:
+				errs[i] = err.Error()
+			case result != nil && result.IsError:
+				errs[i] = result.Content[0].(*mcp.TextContent).Text
+			case len(output.LanguageSummary) != 1:
+				errs[i] = fmt.Sprintf("unexpected summary %+v", output.LanguageSummary)
+			}
+		})
+	}
+	wg.Wait()
+
+	if timedOut {
+		t.Fatalf("expected %d analyses to run at once; they were serialized", n)
+	}
+	for i, e := range errs {
+		if e != "" {
+			t.Errorf("analysis %d: %s", i, e)
+		}
+	}
+}
+
+func TestRunSCC_MissingPath(t *testing.T) {
+	model := defaultCocomo()
+	_, err := RunSCC(context.Background(), filepath.Join(t.TempDir(), "missing"), &model, true, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Fatalf("expected a missing path error, got %v", err)
+	}
+
+	// The worker survives and serves the next analysis.
+	if _, err := RunSCC(context.Background(), t.TempDir(), &model, true, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error after failed analysis: %v", err)
+	}
+}
+
+func TestRunSCC_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	model := defaultCocomo()
+	if _, err := RunSCC(ctx, t.TempDir(), &model, true, nil, nil, nil); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWorkerPool_Stderr(t *testing.T) {
+	t.Setenv("MTB_TEST_WORKER_PANIC", "scc exploded")
+	p := newWorkerPool(1)
+	defer p.close()
+
+	_, err := p.analyze(context.Background(), sccRequest{Path: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "worker failed") || !strings.Contains(err.Error(), "panic: scc exploded") {
+		t.Fatalf("expected the worker's panic in the error, got %v", err)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	var b tailBuffer
+	b.Write([]byte(strings.Repeat("a", workerStderrTail)))
+	b.Write([]byte("end"))
+	if got := b.String(); len(got) != workerStderrTail || !strings.HasSuffix(got, "aend") {
+		t.Errorf("expected the last %d bytes, got %d ending in %q", workerStderrTail, len(got), got[len(got)-4:])
+	}
+}
@@ -5,9 +5,12 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/dbravender/mtb/internal/tools"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -16,45 +19,161 @@ import (
 var version = "0.9.0"
 
 func main() {
+	tools.ServeWorker()
+
 	if len(os.Args) == 2 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
 		fmt.Println("mtb " + version)
 		return
 	}
 
+	if len(os.Args) > 1 && isCommand(os.Args[1]) {
+		code := runCLI(context.Background(), os.Args[1:], os.Stdout, os.Stderr)
+		tools.CloseWorkers()
+		os.Exit(code)
+	}
+
+	fs := flag.NewFlagSet("mtb", flag.ExitOnError)
+	httpAddr := fs.String("http", "", "serve MCP over streamable HTTP on this address (e.g. :8080) instead of stdio")
+	workers := fs.Int("workers", tools.DefaultWorkers, "maximum number of scc analyses to run at once")
+	fs.Parse(os.Args[1:])
+	tools.SetWorkers(*workers)
+	defer tools.CloseWorkers()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := newServer()
+
+	var err error
+	if *httpAddr != "" {
+		err = serveHTTP(ctx, *httpAddr, server)
+	} else {
+		err = server.Run(ctx, &mcp.StdioTransport{})
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newServer returns an MCP server with every mtb tool, resource, and prompt
+// registered.
+func newServer() *mcp.Server {
 	server := mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "mtb",
 			Version: version,
 		},
-		nil,
+		&mcp.ServerOptions{
+			SubscribeHandler:   tools.SubscribeResource,
+			UnsubscribeHandler: tools.UnsubscribeResource,
+		},
 	)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "stats",
-		Description: "Analyze code in a directory using scc. Returns lines of code, comments, blanks, complexity, and COCOMO cost estimates per language. IMPORTANT: Run this BEFORE committing code to check whether your changes increased complexity. If complexity went up significantly, flag it to the user and discuss whether the added complexity is justified. Use this before estimating effort, planning refactors, or assessing project health.",
+		Description: "Analyze code in a directory using scc. Returns lines of code, comments, blanks, complexity, and COCOMO cost estimates per language. Set files for a per-file breakdown, top_n to rank the most complex files, or baseline to diff against a saved snapshot. The COCOMO model (project type, average wage, overhead, EAF, currency) can be set per call or in .mtb.yaml and is echoed in the result. IMPORTANT: Run this BEFORE committing code to check whether your changes increased complexity. If complexity went up significantly, flag it to the user and discuss whether the added complexity is justified. Use this before estimating effort, planning refactors, or assessing project health.",
 	}, tools.HandleStats)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "deps",
-		Description: "Prompt the agent to identify existing project dependencies before suggesting new ones. Returns guidance on which manifest files to check (go.mod, package.json, requirements.txt, Cargo.toml, etc.) and ecosystem-appropriate CLI tools for deeper analysis. IMPORTANT: Always run this before suggesting new dependencies to check if an existing package already covers the need. Every unnecessary dependency increases maintenance cost, security exposure, and build times.",
+		Description: "Identify existing project dependencies before suggesting new ones. Parses go.mod, package.json, requirements*.txt, pyproject.toml, and Cargo.toml under the path and returns each dependency's ecosystem, name, version, direct/indirect status, and manifest location, tags well-known packages with the capability they provide, and reports capabilities covered by more than one direct dependency (e.g. two logging libraries). Reads go.sum, package-lock.json, pnpm-lock.yaml, yarn.lock, Cargo.lock, poetry.lock, and uv.lock offline to count direct and transitive packages and rank the direct dependencies that pull in the largest subtrees. For other ecosystems, returns guidance on which manifest files to check and ecosystem-appropriate CLI tools for deeper analysis. IMPORTANT: Always run this before suggesting new dependencies to check if an existing package already covers the need. Every unnecessary dependency increases maintenance cost, security exposure, and build times.",
 	}, tools.HandleDeps)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "check_dependency",
+		Description: "Check whether a project needs a package before adding it. Takes a candidate package and the project path, looks the candidate up in mtb's capability taxonomy, and compares it with the dependencies parsed from the project's manifests and with the language's standard library (e.g. github.com/pkg/errors → errors, golang.org/x/exp/slices → slices, node-fetch → fetch). Returns a verdict: covered (the project already depends on it or on another package with the same capability), stdlib (the standard library covers it), or new (a new capability), with the covering dependencies and the reason. IMPORTANT: Run this before adding any dependency, and if the verdict is covered or stdlib, use what the project already has instead unless the user explicitly approves the addition.",
+	}, tools.HandleCheckDependency)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "dep_weight",
+		Description: "Measure how much code each dependency drags in. Parses the project's manifests, locates each dependency's source on disk (replace targets, vendor/ and the Go module cache, node_modules, site-packages in .venv or venv, cargo's vendor/ and registry sources), runs scc on it, and returns code lines, complexity, and COCOMO cost per dependency, heaviest first, with a markdown table and totals. Dependencies whose source is not on disk are listed as missing. Set include_indirect to weigh indirect dependencies listed in the manifests too. IMPORTANT: Present the heaviest dependencies to the user and point out ones that bring in far more code than the project uses them for; never remove a dependency without the user's approval.",
+	}, tools.HandleDepWeight)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "preview_dependency",
+		Description: "Preview what adding a Go module would cost BEFORE running go get. Resolves the module and version from the module cache or a GOPROXY (goproxy input or preview.goproxy in .mtb.yaml; off, the default, works offline from the cache, and a file:// proxy works offline too), runs go get on a scratch copy of go.mod and go.sum, and returns the new go.sum lines, the new modules the candidate's packages need and the required ones whose version would change, each with its license and scc size, plus a compare-style before/after delta table of the added code. The project's files are not modified. IMPORTANT: Present the new modules, their licenses, and the delta to the user and ask whether the dependency is worth it before adding it; call check_dependency first to see whether the project or the standard library already covers it.",
+	}, tools.HandlePreviewDependency)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "consult",
-		Description: "Get a structured consultation before implementing a new feature or adding a dependency. Use this BEFORE writing any new feature code. Takes a problem description, scans the project for relevant existing dependencies, and returns a set of questions the agent MUST present to the user before proceeding. IMPORTANT: When a user asks you to build something non-trivial, call consult first. Present each returned question to the user and wait for their answers. Do NOT skip questions or proceed until the user has considered the tradeoffs.",
+		Description: "Get a structured consultation before implementing a new feature or adding a dependency. Use this BEFORE writing any new feature code. Takes a problem description, scans the project for relevant existing dependencies, matches it against a catalog of existing solutions, searches GitHub for popular repositories (filtered by language when given) when consult.search in .mtb.yaml enables it, and returns a set of questions the agent MUST present to the user before proceeding, along with a session_id for recording the answers with consult_answer. When the client supports elicitation, each question, its follow-ups, and the final decision are shown to the user directly as forms, and the answers are returned. IMPORTANT: When a user asks you to build something non-trivial, call consult first. Present each returned question to the user and wait for their answers. Do NOT skip questions or proceed until the user has considered the tradeoffs.",
 	}, tools.HandleConsult)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "consult_answer",
+		Description: "Record the user's answers in a consult session and get follow-up questions. consult returns a session_id; after the user answers a question, call this with the session_id, question_id, and the user's answer in their own words. It returns follow-up \"why\" questions generated from the answer and the questions still pending. When every question is answered, ask the user to decide and record the resolution (build, buy/adopt, or abandon) with a rationale. Sessions are saved under .mtb/sessions from the first answer, so a later conversation can resume one; call without a session_id to list them. IMPORTANT: Only record answers the user actually gave; never answer on their behalf.",
+	}, tools.HandleConsultAnswer)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "adr",
+		Description: "Write an Architecture Decision Record so the reasoning behind a build-vs-adopt decision isn't lost. Pass a resolved consult session_id to record its problem, the alternatives found, the user's answers, and the decision, or give them explicitly. Writes a numbered Markdown record in MADR or Nygard format to docs/adr (configurable with adr.dir and adr.format in .mtb.yaml) and regenerates the index there. IMPORTANT: Record only the decision and reasoning the user actually gave, then show them the record to correct.",
+	}, tools.HandleADR)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "checklist",
-		Description: "Evaluate a project's operational readiness. Use this before shipping code to check whether CI, monitoring, on-call, security, deployment, and documentation concerns are necessary and have been addressed. Returns checklist items the agent MUST present to the user. IMPORTANT: Present each item and wait for the user's answer before proceeding.",
+		Description: "Evaluate a project's operational readiness. Use this before shipping code to check whether CI, monitoring, on-call, security, deployment, and documentation concerns are necessary and have been addressed. Returns checklist items the agent MUST present to the user. When a path is given, scans it for evidence (CI configs, test files, probes, SECURITY.md, vulnerability scanners, deploy pipelines, runbooks) and attaches a detected status and supporting files to each item. When the client supports elicitation, each item is shown to the user directly as a form and their answers are returned. IMPORTANT: Present each item and wait for the user's answer before proceeding.",
 	}, tools.HandleChecklist)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "compare",
-		Description: "Prompt the agent to measure the complexity impact of code changes. Use this after completing a task to check whether the changes increased complexity. Instructs the agent to run stats before and after changes, compare lines of code, complexity, and estimated cost, then present the delta to the user. IMPORTANT: The agent MUST present the before/after comparison and discuss whether the added complexity is justified.",
+		Description: "Measure the complexity impact of code changes. Use this after completing a task to check whether the changes increased complexity. Analyzes two git refs (default: HEAD vs. the working tree), or a saved snapshot and the working tree, with scc without touching the working tree, and returns a per-language before/after delta of lines of code, complexity, and estimated cost as structured data and a markdown table. IMPORTANT: The agent MUST present the before/after comparison and discuss whether the added complexity is justified.",
 	}, tools.HandleCompare)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-		log.Fatal(err)
-	}
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "hotspots",
+		Description: "Find the files most worth simplifying: those that are both complex and frequently changed. Reads the git history of a directory over a window (default the last year), counts commits and lines added and deleted per file, joins them with each file's current scc complexity, and returns the files ranked by commits × complexity as structured data and a markdown table. Use this before planning a refactor or when deciding where tests would pay off most. IMPORTANT: Present the ranked hotspots to the user and let them decide which are worth the effort; do not start refactoring on your own.",
+	}, tools.HandleHotspots)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "trend",
+		Description: "Chart how code size, complexity, and COCOMO cost grew across git history. Samples the commits that touched a directory (the last commit of each week or month, or every N commits) since a git date (default 1 year ago), analyzes each commit's tree with scc without touching the working tree, and returns a per-language time series with a markdown table and an optional sparkline or CSV. Results are cached per commit under .mtb/trend, so reruns are cheap. Use this to show how a codebase's complexity evolved, e.g. when arguing for or against a cleanup. IMPORTANT: Present the trend to the user and point out where complexity grew faster than code.",
+	}, tools.HandleTrend)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "gate",
+		Description: "Check staged changes against the project's complexity budget before committing. Compares the staged tree with HEAD using scc and checks the growth in complexity, code lines, and estimated cost against the budgets in .mtb.yaml, including per-language overrides. Returns whether the change passed, the violations, and a before/after table. IMPORTANT: If the gate fails, present the violations to the user and discuss simplifying or splitting the change; never raise the budget without the user's approval.",
+	}, tools.HandleGate)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "snapshot",
+		Description: "Save a named baseline of the stats for a directory, so the before numbers survive across long sessions and context compaction. Snapshots are stored under .mtb/snapshots in the repository with the timestamp, git commit, and filters used. Use action=save before starting a change, then pass the name as base_snapshot to compare or baseline to stats to see how complexity moved. action=list shows saved snapshots and action=delete removes one.",
+	}, tools.HandleSnapshot)
+
+	tools.AddResources(server)
+
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "mtb-consult",
+		Title:       "Consult before building",
+		Description: "Work through mtb's consultation questions, existing alternatives, and matching repositories before building something new.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "problem", Description: "what you want to build or the problem you want to solve", Required: true},
+			{Name: "path", Description: "project directory to scan for existing dependencies"},
+			{Name: "language", Description: "only suggest repositories written in this language"},
+		},
+	}, tools.HandleConsultPrompt)
+
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "mtb-checklist",
+		Title:       "Operational readiness checklist",
+		Description: "Review a project's CI, monitoring, on-call, security, deployment, and documentation readiness item by item.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "project", Description: "description of the project being evaluated", Required: true},
+			{Name: "path", Description: "project directory to scan for evidence"},
+		},
+	}, tools.HandleChecklistPrompt)
+
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "mtb-compare",
+		Title:       "Complexity impact of changes",
+		Description: "Compare complexity before and after a change and discuss whether the increase is justified.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "project", Description: "description of the project being evaluated", Required: true},
+			{Name: "path", Description: "directory inside the git repository to compare (default .)"},
+			{Name: "base", Description: "git ref for the before side (default HEAD)"},
+			{Name: "head", Description: "git ref for the after side (default: the working tree)"},
+			{Name: "base_snapshot", Description: "saved snapshot to use as the before side instead of a git ref"},
+		},
+	}, tools.HandleComparePrompt)
+
+	return server
 }